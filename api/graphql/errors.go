@@ -0,0 +1,27 @@
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/rithikjain/quickscan-backend/api/view"
+)
+
+// FormatErrors rewrites resolver errors the way view.Wrap reports them
+// over REST: the message a client may show, plus the stable code, the HTTP
+// status the error would have had and any invalid fields as extensions.
+// Errors found while parsing or validating the query are left as they are.
+func FormatErrors(errs []*errors.QueryError) {
+	for _, e := range errs {
+		if e.ResolverError == nil {
+			continue
+		}
+		problem := view.ProblemOf(e.ResolverError)
+		e.Message = problem.Message
+		e.Extensions = map[string]interface{}{
+			"code":   problem.Code,
+			"status": problem.Status,
+		}
+		if len(problem.Errors) > 0 {
+			e.Extensions["errors"] = problem.Errors
+		}
+	}
+}
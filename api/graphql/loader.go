@@ -0,0 +1,131 @@
+package graphql
+
+import (
+	"context"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"sync"
+)
+
+type loadersKey struct{}
+
+// Loaders batch the lookups of a single request. They cache what they load,
+// so a fresh set is needed for every request.
+type Loaders struct {
+	items *itemLoader
+	carts *cartLoader
+}
+
+// WithLoaders returns a context carrying fresh Loaders for one request.
+func WithLoaders(ctx context.Context, cartSvc cart.Service) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &Loaders{
+		items: &itemLoader{
+			svc:     cartSvc,
+			pending: map[string]bool{},
+			loaded:  map[string][]entities.CartItem{},
+		},
+		carts: &cartLoader{
+			svc:     cartSvc,
+			pending: map[string]bool{},
+			loaded:  map[string]*entities.Cart{},
+		},
+	})
+}
+
+func loadersFrom(ctx context.Context) *Loaders {
+	return ctx.Value(loadersKey{}).(*Loaders)
+}
+
+// itemLoader loads the items of carts. Every cart resolver queues its cart
+// when it is created, and the first cart whose items are needed fetches the
+// items of all queued carts in one query. Listing n carts with their items
+// therefore costs one query instead of n.
+type itemLoader struct {
+	svc cart.Service
+
+	mu      sync.Mutex
+	pending map[string]bool
+	loaded  map[string][]entities.CartItem
+}
+
+// queue marks the cart's items to be fetched with the next batch.
+func (l *itemLoader) queue(cartID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.loaded[cartID]; !ok {
+		l.pending[cartID] = true
+	}
+}
+
+func (l *itemLoader) load(cartID string) ([]entities.CartItem, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if items, ok := l.loaded[cartID]; ok {
+		return items, nil
+	}
+
+	l.pending[cartID] = true
+	ids := make([]string, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	byCart, err := l.svc.GetItemsOfCarts(ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, items := range byCart {
+		l.loaded[id] = items
+	}
+	l.pending = map[string]bool{}
+	return l.loaded[cartID], nil
+}
+
+// forget drops the cached items of a cart after a mutation changed them.
+func (l *itemLoader) forget(cartID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.loaded, cartID)
+}
+
+// cartLoader loads carts by ID the way itemLoader loads items, so that
+// listing n orders with their carts costs one query instead of n.
+type cartLoader struct {
+	svc cart.Service
+
+	mu      sync.Mutex
+	pending map[string]bool
+	loaded  map[string]*entities.Cart
+}
+
+// queue marks the cart to be fetched with the next batch.
+func (l *cartLoader) queue(cartID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.loaded[cartID]; !ok {
+		l.pending[cartID] = true
+	}
+}
+
+// load returns the cart, or nil when it does not exist or is in the trash.
+func (l *cartLoader) load(cartID string) (*entities.Cart, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := l.loaded[cartID]; ok {
+		return c, nil
+	}
+
+	l.pending[cartID] = true
+	ids := make([]string, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	byID, err := l.svc.GetCartsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		l.loaded[id] = byID[id]
+	}
+	l.pending = map[string]bool{}
+	return l.loaded[cartID], nil
+}
@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Money is an amount in the smallest unit of its currency. GraphQL's Int is
+// 32 bits, which a cart total or budget in paise or cents can outgrow, so
+// amounts have a scalar of their own that is written as a JSON number.
+type Money int64
+
+// maxSafeMoney is the largest amount a JSON number holds exactly.
+const maxSafeMoney = 1<<53 - 1
+
+func (Money) ImplementsGraphQLType(name string) bool {
+	return name == "Money"
+}
+
+// UnmarshalGraphQL accepts whole numbers as literals or variables, and
+// strings of digits for clients that cannot send large numbers exactly.
+func (m *Money) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case int32:
+		*m = Money(input)
+	case int64:
+		*m = Money(input)
+	case float64:
+		if input != math.Trunc(input) || math.Abs(input) > maxSafeMoney {
+			return fmt.Errorf("Money must be a whole number, got %v", input)
+		}
+		*m = Money(input)
+	case string:
+		n, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Money must be a whole number, got %q", input)
+		}
+		*m = Money(n)
+	default:
+		return fmt.Errorf("Money must be a whole number, got %T", input)
+	}
+	return nil
+}
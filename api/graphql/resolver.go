@@ -0,0 +1,299 @@
+package graphql
+
+import (
+	"context"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+// Resolver resolves the Query and Mutation types. Every field acts for the
+// user of the request's token and only reaches that user's data.
+type Resolver struct {
+	users user.Service
+	carts cart.Service
+}
+
+func userID(ctx context.Context) (string, error) {
+	claims, err := middleware.ValidateAndGetClaims(ctx, middleware.AllRoles...)
+	if err != nil {
+		return "", err
+	}
+	return claims["id"].(string), nil
+}
+
+// ownCart fetches the cart and checks that it belongs to the user.
+func (r *Resolver) ownCart(cartID, userID string) (*entities.Cart, error) {
+	c, err := r.carts.GetCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	return c, nil
+}
+
+// ownItem fetches the item and checks that its cart belongs to the user.
+func (r *Resolver) ownItem(itemID, userID string) (*entities.CartItem, error) {
+	item, err := r.carts.GetCartItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.ownCart(item.CartID, userID); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *Resolver) Me(ctx context.Context) (*userResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := r.users.GetUserByUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{u: u, carts: r.carts}, nil
+}
+
+type idArgs struct {
+	ID graphqlgo.ID
+}
+
+func (r *Resolver) Cart(ctx context.Context, args idArgs) (*cartResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.ownCart(string(args.ID), id)
+	if err != nil {
+		return nil, err
+	}
+	return newCartResolver(ctx, c), nil
+}
+
+func (r *Resolver) Orders(ctx context.Context) ([]*orderResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := r.carts.GetOrders(id)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*orderResolver, len(*orders))
+	for i := range *orders {
+		resolvers[i] = newOrderResolver(ctx, &(*orders)[i])
+	}
+	return resolvers, nil
+}
+
+func (r *Resolver) Order(ctx context.Context, args idArgs) (*orderResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.carts.GetOrder(string(args.ID), id)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderResolver(ctx, o), nil
+}
+
+type profileInput struct {
+	Name        *string `json:"name" validate:"min=1,max=100"`
+	PhoneNumber *string `json:"phoneNumber"`
+	ImageUrl    *string `json:"imageUrl" validate:"max=2048"`
+}
+
+func (r *Resolver) UpdateProfile(ctx context.Context, args struct{ Input profileInput }) (*userResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(&args.Input); err != nil {
+		return nil, err
+	}
+	u, err := r.users.UpdateProfile(id, &user.ProfileUpdate{
+		Name:        args.Input.Name,
+		PhoneNumber: args.Input.PhoneNumber,
+		ImageUrl:    args.Input.ImageUrl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{u: u, carts: r.carts}, nil
+}
+
+type createCartInput struct {
+	Name     string           `json:"name" validate:"required,max=100"`
+	StoreId  *string          `json:"storeId" validate:"uuid"`
+	Location *entities.LatLng `json:"location"`
+}
+
+func (r *Resolver) CreateCart(ctx context.Context, args struct{ Input createCartInput }) (*cartResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(&args.Input); err != nil {
+		return nil, err
+	}
+	c, err := r.carts.CreateCart(&entities.Cart{
+		CartName: args.Input.Name,
+		StoreID:  deref(args.Input.StoreId),
+		UserID:   id,
+	}, args.Input.Location)
+	if err != nil {
+		return nil, err
+	}
+	return newCartResolver(ctx, c), nil
+}
+
+type renameCartArgs struct {
+	ID   graphqlgo.ID `json:"id" validate:"uuid"`
+	Name string       `json:"name" validate:"required,max=100"`
+}
+
+func (r *Resolver) RenameCart(ctx context.Context, args renameCartArgs) (*cartResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(&args); err != nil {
+		return nil, err
+	}
+	if _, err := r.ownCart(string(args.ID), id); err != nil {
+		return nil, err
+	}
+	c, err := r.carts.ChangeCartName(string(args.ID), args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return newCartResolver(ctx, c), nil
+}
+
+func (r *Resolver) ArchiveCart(ctx context.Context, args struct {
+	ID       graphqlgo.ID
+	Archived bool
+}) (*cartResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.carts.ArchiveCart(string(args.ID), id, args.Archived)
+	if err != nil {
+		return nil, err
+	}
+	return newCartResolver(ctx, c), nil
+}
+
+func (r *Resolver) DeleteCart(ctx context.Context, args idArgs) (bool, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := r.carts.DeleteCart(string(args.ID), id); err != nil {
+		return false, err
+	}
+	loadersFrom(ctx).items.forget(string(args.ID))
+	return true, nil
+}
+
+type addItemInput struct {
+	CartId    graphqlgo.ID `json:"cartId" validate:"required,uuid"`
+	ProductId *string      `json:"productId" validate:"uuid"`
+	Name      *string      `json:"name" validate:"max=200"`
+	Price     *Money       `json:"price" validate:"min=0,max=100000000"`
+	Quantity  int32        `json:"quantity" validate:"required,min=1,max=1000"`
+	ImageUrl  *string      `json:"imageUrl" validate:"max=2048"`
+}
+
+func (r *Resolver) AddItem(ctx context.Context, args struct{ Input addItemInput }) (*itemResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in := args.Input
+	if err := request.Validate(in); err != nil {
+		return nil, err
+	}
+	if _, err := r.ownCart(string(in.CartId), id); err != nil {
+		return nil, err
+	}
+
+	item := &entities.CartItem{
+		CartID:       string(in.CartId),
+		ProductID:    deref(in.ProductId),
+		ItemName:     deref(in.Name),
+		ItemQuantity: int(in.Quantity),
+		ItemImageUrl: deref(in.ImageUrl),
+	}
+	if in.Price != nil {
+		item.ItemPrice = int(*in.Price)
+	}
+	item, _, err = r.carts.CreateCartItem(item)
+	if err != nil {
+		return nil, err
+	}
+	loadersFrom(ctx).items.forget(item.CartID)
+	return &itemResolver{item: item}, nil
+}
+
+type updateItemCountArgs struct {
+	ID    graphqlgo.ID `json:"id" validate:"uuid"`
+	Count int32        `json:"count" validate:"required,min=1,max=1000"`
+}
+
+func (r *Resolver) UpdateItemCount(ctx context.Context, args updateItemCountArgs) (*itemResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(&args); err != nil {
+		return nil, err
+	}
+	if _, err := r.ownItem(string(args.ID), id); err != nil {
+		return nil, err
+	}
+	item, _, err := r.carts.UpdateCartItemCount(string(args.ID), int(args.Count))
+	if err != nil {
+		return nil, err
+	}
+	loadersFrom(ctx).items.forget(item.CartID)
+	return &itemResolver{item: item}, nil
+}
+
+func (r *Resolver) RemoveItem(ctx context.Context, args idArgs) (bool, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return false, err
+	}
+	item, err := r.ownItem(string(args.ID), id)
+	if err != nil {
+		return false, err
+	}
+	if err := r.carts.DeleteCartItem(item.UUID); err != nil {
+		return false, err
+	}
+	loadersFrom(ctx).items.forget(item.CartID)
+	return true, nil
+}
+
+func (r *Resolver) Checkout(ctx context.Context, args struct{ CartId graphqlgo.ID }) (*orderResolver, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.carts.Checkout(string(args.CartId), id)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderResolver(ctx, o), nil
+}
@@ -0,0 +1,137 @@
+// Package graphql serves the user and cart services as a GraphQL schema, so
+// a client can fetch a user, their carts and the items of those carts in a
+// single request.
+package graphql
+
+import (
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+# An amount in the smallest unit of its currency
+scalar Money
+
+enum SortKey {
+	CREATED
+	UPDATED
+	NAME
+	PRICE
+}
+
+enum SortOrder {
+	ASC
+	DESC
+}
+
+type Query {
+	me: User!
+	cart(id: ID!): Cart!
+	orders: [Order!]!
+	order(id: ID!): Order!
+}
+
+type Mutation {
+	updateProfile(input: ProfileInput!): User!
+	createCart(input: CreateCartInput!): Cart!
+	renameCart(id: ID!, name: String!): Cart!
+	archiveCart(id: ID!, archived: Boolean!): Cart!
+	deleteCart(id: ID!): Boolean!
+	addItem(input: AddItemInput!): CartItem!
+	updateItemCount(id: ID!, count: Int!): CartItem!
+	removeItem(id: ID!): Boolean!
+	checkout(cartId: ID!): Order!
+}
+
+type User {
+	id: ID!
+	name: String!
+	email: String!
+	phoneNumber: String!
+	imageUrl: String!
+	totpEnabled: Boolean!
+	carts(first: Int, after: String, search: String, from: String, to: String, archived: Boolean, sort: SortKey, order: SortOrder): CartPage!
+}
+
+type CartPage {
+	carts: [Cart!]!
+	nextCursor: String
+}
+
+type Cart {
+	id: ID!
+	name: String!
+	storeId: String!
+	budget: Money!
+	createdAt: Time!
+	updatedAt: Time!
+	checkedOutAt: Time
+	archivedAt: Time
+	items: [CartItem!]!
+	total: Money!
+}
+
+type CartItem {
+	id: ID!
+	cartId: ID!
+	productId: String!
+	name: String!
+	price: Money!
+	quantity: Int!
+	imageUrl: String!
+	createdAt: Time!
+	updatedAt: Time!
+}
+
+type Order {
+	id: ID!
+	cartId: ID!
+	storeId: String!
+	total: Money!
+	currency: String!
+	itemCount: Int!
+	status: String!
+	completedAt: Time!
+	cart: Cart
+}
+
+input ProfileInput {
+	name: String
+	phoneNumber: String
+	imageUrl: String
+}
+
+input LocationInput {
+	latitude: Float!
+	longitude: Float!
+}
+
+input CreateCartInput {
+	name: String!
+	storeId: String
+	location: LocationInput
+}
+
+input AddItemInput {
+	cartId: ID!
+	productId: String
+	name: String
+	price: Money
+	quantity: Int!
+	imageUrl: String
+}
+`
+
+// NewSchema parses the schema against resolvers backed by the services.
+// Requests must carry Loaders in their context, see WithLoaders.
+func NewSchema(userSvc user.Service, cartSvc cart.Service) *graphqlgo.Schema {
+	return graphqlgo.MustParseSchema(schema, &Resolver{users: userSvc, carts: cartSvc})
+}
@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+// More than fits a GraphQL Int
+const (
+	bigBudget = 5000000000
+	bigPrice  = 3000000000
+)
+
+type fakeUsers struct{ user.Service }
+
+func (fakeUsers) GetUserByUUID(id string) (*entities.User, error) {
+	return &entities.User{UUID: id, Name: "Ann"}, nil
+}
+
+type fakeCarts struct {
+	cart.Service
+	created *entities.CartItem
+}
+
+func (*fakeCarts) ListCarts(userID string, opts cart.ListOptions) (*cart.CartPage, error) {
+	return &cart.CartPage{Carts: []entities.Cart{{UUID: "c1", UserID: userID, Budget: bigBudget}}}, nil
+}
+
+func (*fakeCarts) GetItemsOfCarts(cartIDs []string) (map[string][]entities.CartItem, error) {
+	return map[string][]entities.CartItem{
+		"c1": {{UUID: "i1", CartID: "c1", ItemPrice: bigPrice, ItemQuantity: 2}},
+	}, nil
+}
+
+func (*fakeCarts) GetOrders(userID string) (*[]entities.Order, error) {
+	return &[]entities.Order{{UUID: "o1", UserID: userID, Total: 2 * bigPrice, ItemCount: 2}}, nil
+}
+
+func (*fakeCarts) GetCart(cartID string) (*entities.Cart, error) {
+	return &entities.Cart{UUID: cartID, UserID: "u1"}, nil
+}
+
+func (c *fakeCarts) CreateCartItem(item *entities.CartItem) (*entities.CartItem, *cart.BudgetReport, error) {
+	c.created = item
+	return item, nil, nil
+}
+
+func exec(t *testing.T, carts *fakeCarts, query string, variables map[string]interface{}) (map[string]interface{}, []string) {
+	t.Helper()
+	ctx := middleware.WithToken(context.Background(), &jwt.Token{Claims: jwt.MapClaims{"id": "u1", "role": entities.RoleUser}})
+	ctx = WithLoaders(ctx, carts)
+	resp := NewSchema(fakeUsers{}, carts).Exec(ctx, query, "", variables)
+	var errs []string
+	for _, e := range resp.Errors {
+		errs = append(errs, e.Message)
+	}
+	var data map[string]interface{}
+	if resp.Data != nil {
+		dec := json.NewDecoder(strings.NewReader(string(resp.Data)))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data, errs
+}
+
+func TestNewSchema(t *testing.T) {
+	// Parsing checks every field of the schema against the resolvers
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("schema does not match the resolvers: %v", err)
+		}
+	}()
+	NewSchema(nil, nil)
+}
+
+func TestLargeAmounts(t *testing.T) {
+	data, errs := exec(t, &fakeCarts{}, `{
+		me { carts { carts { budget total items { price quantity } } } }
+		orders { total itemCount }
+	}`, nil)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	c := data["me"].(map[string]interface{})["carts"].(map[string]interface{})["carts"].([]interface{})[0].(map[string]interface{})
+	item := c["items"].([]interface{})[0].(map[string]interface{})
+	order := data["orders"].([]interface{})[0].(map[string]interface{})
+	tests := map[string]interface{}{
+		"budget":      c["budget"],
+		"total":       c["total"],
+		"price":       item["price"],
+		"order total": order["total"],
+	}
+	want := map[string]string{
+		"budget":      "5000000000",
+		"total":       "6000000000",
+		"price":       "3000000000",
+		"order total": "6000000000",
+	}
+	for name, got := range tests {
+		if n, ok := got.(json.Number); !ok || n.String() != want[name] {
+			t.Errorf("%s = %#v, want the number %s", name, got, want[name])
+		}
+	}
+}
+
+func TestAddItemPrice(t *testing.T) {
+	const mutation = `mutation($price: Money) {
+		addItem(input: {cartId: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", name: "Milk", price: $price, quantity: 1}) { price }
+	}`
+	tests := []struct {
+		name  string
+		price interface{}
+		want  int
+		ok    bool
+	}{
+		{"number", float64(1250), 1250, true},
+		{"string", "99999999", 99999999, true},
+		{"fraction", 12.5, 0, false},
+		{"not a number", "twelve", 0, false},
+		{"above the limit", float64(100000001), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			carts := &fakeCarts{}
+			_, errs := exec(t, carts, mutation, map[string]interface{}{"price": tt.price})
+			if tt.ok != (len(errs) == 0) {
+				t.Fatalf("errors %v, want ok %v", errs, tt.ok)
+			}
+			if tt.ok && (carts.created == nil || carts.created.ItemPrice != tt.want) {
+				t.Errorf("created %+v, want price %d", carts.created, tt.want)
+			}
+		})
+	}
+
+	// Literals are read as well
+	carts := &fakeCarts{}
+	literal := `mutation {
+		addItem(input: {cartId: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", name: "Milk", price: 250, quantity: 1}) { price }
+	}`
+	if _, errs := exec(t, carts, literal, nil); len(errs) > 0 || carts.created.ItemPrice != 250 {
+		t.Errorf("literal price: %v, %+v", errs, carts.created)
+	}
+}
@@ -0,0 +1,271 @@
+package graphql
+
+import (
+	"context"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strings"
+)
+
+type userResolver struct {
+	u     *entities.User
+	carts cart.Service
+}
+
+func (r *userResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.u.UUID)
+}
+
+func (r *userResolver) Name() string {
+	return r.u.Name
+}
+
+func (r *userResolver) Email() string {
+	return r.u.Email
+}
+
+func (r *userResolver) PhoneNumber() string {
+	return r.u.PhoneNumber
+}
+
+func (r *userResolver) ImageUrl() string {
+	return r.u.ImageUrl
+}
+
+func (r *userResolver) TotpEnabled() bool {
+	return r.u.TOTPEnabled
+}
+
+type cartsArgs struct {
+	First    *int32
+	After    *string
+	Search   *string
+	From     *string
+	To       *string
+	Archived *bool
+	Sort     *string
+	Order    *string
+}
+
+func (r *userResolver) Carts(ctx context.Context, args cartsArgs) (*cartPageResolver, error) {
+	opts := cart.ListOptions{
+		Search: deref(args.Search),
+		From:   deref(args.From),
+		To:     deref(args.To),
+		Sort:   strings.ToLower(deref(args.Sort)),
+		Order:  strings.ToLower(deref(args.Order)),
+		Cursor: deref(args.After),
+	}
+	if args.First != nil {
+		opts.Limit = int(*args.First)
+	}
+	if args.Archived != nil && *args.Archived {
+		opts.Archived = "true"
+	} else if args.Archived != nil {
+		opts.Archived = "false"
+	}
+
+	page, err := r.carts.ListCarts(r.u.UUID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cartPageResolver{ctx: ctx, page: page}, nil
+}
+
+type cartPageResolver struct {
+	ctx  context.Context
+	page *cart.CartPage
+}
+
+func (r *cartPageResolver) Carts() []*cartResolver {
+	carts := make([]*cartResolver, len(r.page.Carts))
+	for i := range r.page.Carts {
+		carts[i] = newCartResolver(r.ctx, &r.page.Carts[i])
+	}
+	return carts
+}
+
+func (r *cartPageResolver) NextCursor() *string {
+	if r.page.NextCursor == "" {
+		return nil
+	}
+	return &r.page.NextCursor
+}
+
+type cartResolver struct {
+	c *entities.Cart
+}
+
+// newCartResolver queues the cart with the item loader, so the items of
+// sibling carts are fetched together.
+func newCartResolver(ctx context.Context, c *entities.Cart) *cartResolver {
+	loadersFrom(ctx).items.queue(c.UUID)
+	return &cartResolver{c: c}
+}
+
+func (r *cartResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.c.UUID)
+}
+
+func (r *cartResolver) Name() string {
+	return r.c.CartName
+}
+
+func (r *cartResolver) StoreId() string {
+	return r.c.StoreID
+}
+
+func (r *cartResolver) Budget() Money {
+	return Money(r.c.Budget)
+}
+
+func (r *cartResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.c.CreatedAt}
+}
+
+func (r *cartResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.c.UpdatedAt}
+}
+
+func (r *cartResolver) CheckedOutAt() *graphqlgo.Time {
+	if r.c.CheckedOutAt == nil {
+		return nil
+	}
+	return &graphqlgo.Time{Time: *r.c.CheckedOutAt}
+}
+
+func (r *cartResolver) ArchivedAt() *graphqlgo.Time {
+	if r.c.ArchivedAt == nil {
+		return nil
+	}
+	return &graphqlgo.Time{Time: *r.c.ArchivedAt}
+}
+
+func (r *cartResolver) Items(ctx context.Context) ([]*itemResolver, error) {
+	items, err := loadersFrom(ctx).items.load(r.c.UUID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*itemResolver, len(items))
+	for i := range items {
+		resolvers[i] = &itemResolver{item: &items[i]}
+	}
+	return resolvers, nil
+}
+
+// Total is worked out from the loaded items rather than with a query of
+// its own per cart.
+func (r *cartResolver) Total(ctx context.Context) (Money, error) {
+	items, err := loadersFrom(ctx).items.load(r.c.UUID)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, item := range items {
+		total += item.ItemPrice * item.ItemQuantity
+	}
+	return Money(total), nil
+}
+
+type itemResolver struct {
+	item *entities.CartItem
+}
+
+func (r *itemResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.item.UUID)
+}
+
+func (r *itemResolver) CartId() graphqlgo.ID {
+	return graphqlgo.ID(r.item.CartID)
+}
+
+func (r *itemResolver) ProductId() string {
+	return r.item.ProductID
+}
+
+func (r *itemResolver) Name() string {
+	return r.item.ItemName
+}
+
+func (r *itemResolver) Price() Money {
+	return Money(r.item.ItemPrice)
+}
+
+func (r *itemResolver) Quantity() int32 {
+	return int32(r.item.ItemQuantity)
+}
+
+func (r *itemResolver) ImageUrl() string {
+	return r.item.ItemImageUrl
+}
+
+func (r *itemResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.item.CreatedAt}
+}
+
+func (r *itemResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.item.UpdatedAt}
+}
+
+type orderResolver struct {
+	o *entities.Order
+}
+
+// newOrderResolver queues the order's cart with the cart loader, so that
+// the carts of a list of orders are fetched together.
+func newOrderResolver(ctx context.Context, o *entities.Order) *orderResolver {
+	loadersFrom(ctx).carts.queue(o.CartID)
+	return &orderResolver{o: o}
+}
+
+func (r *orderResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.o.UUID)
+}
+
+func (r *orderResolver) CartId() graphqlgo.ID {
+	return graphqlgo.ID(r.o.CartID)
+}
+
+func (r *orderResolver) StoreId() string {
+	return r.o.StoreID
+}
+
+func (r *orderResolver) Total() Money {
+	return Money(r.o.Total)
+}
+
+func (r *orderResolver) Currency() string {
+	return r.o.Currency
+}
+
+func (r *orderResolver) ItemCount() int32 {
+	return int32(r.o.ItemCount)
+}
+
+func (r *orderResolver) Status() string {
+	return r.o.Status
+}
+
+func (r *orderResolver) CompletedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.o.CompletedAt}
+}
+
+func (r *orderResolver) Cart(ctx context.Context) (*cartResolver, error) {
+	c, err := loadersFrom(ctx).carts.load(r.o.CartID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		// The cart was deleted after checkout, the order stands on its own
+		return nil, nil
+	}
+	return newCartResolver(ctx, c), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/shoppinglist"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"net/http"
+	"time"
+)
+
+// Protected Request
+func deleteAccount(userSvc user.Service, cartSvc cart.Service, listSvc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			Password string `json:"password"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		removeData := func(userID string) error {
+			if err := cartSvc.DeleteUserData(userID); err != nil {
+				return err
+			}
+			return listSvc.DeleteUserData(userID)
+		}
+		err = userSvc.DeleteAccount(claims["id"].(string), req.Password, middleware.ClientIP(r), removeData)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Account Deleted",
+		})
+	})
+}
+
+type exportedCart struct {
+	entities.Cart
+	Items []entities.CartItem `json:"items"`
+}
+
+type exportedList struct {
+	entities.ShoppingList
+	Items []entities.ShoppingListEntry `json:"items"`
+}
+
+// Protected Request
+func exportData(userSvc user.Service, cartSvc cart.Service, listSvc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		userID := claims["id"].(string)
+
+		u, err := userSvc.GetUserByUUID(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		carts, err := cartSvc.GetCarts(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		exported := make([]exportedCart, 0, len(*carts))
+		for _, c := range *carts {
+			items, err := cartSvc.GetCartItems(c.UUID)
+			if err != nil {
+				view.Wrap(err, w)
+				return
+			}
+			exported = append(exported, exportedCart{Cart: c, Items: *items})
+		}
+
+		trash, err := cartSvc.GetTrash(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		budget, err := cartSvc.GetBudgetReport("", userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		orders, err := cartSvc.GetOrders(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		templates, err := cartSvc.GetTemplates(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		exportedTemplates := make([]cart.TemplateDetails, 0, len(*templates))
+		for _, t := range *templates {
+			details, err := cartSvc.GetTemplate(t.UUID, userID)
+			if err != nil {
+				view.Wrap(err, w)
+				return
+			}
+			exportedTemplates = append(exportedTemplates, *details)
+		}
+
+		lists, err := listSvc.GetLists(userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		exportedLists := make([]exportedList, 0, len(*lists))
+		for _, l := range *lists {
+			entries, err := listSvc.GetEntries(l.UUID, userID)
+			if err != nil {
+				view.Wrap(err, w)
+				return
+			}
+			exportedLists = append(exportedLists, exportedList{ShoppingList: l, Items: *entries})
+		}
+
+		exportedAt := time.Now().UTC()
+		filename := fmt.Sprintf("qwikscan-export-%s", exportedAt.Format("20060102"))
+
+		if r.URL.Query().Get("format") == "zip" {
+			w.Header().Add("Content-Type", "application/zip")
+			w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+			zw := zip.NewWriter(w)
+			files := map[string]interface{}{
+				"profile.json":   u,
+				"carts.json":     exported,
+				"trash.json":     trash,
+				"orders.json":    orders,
+				"budget.json":    budget.Monthly,
+				"lists.json":     exportedLists,
+				"templates.json": exportedTemplates,
+			}
+			for name, data := range files {
+				f, err := zw.Create(name)
+				if err != nil {
+					return
+				}
+				enc := json.NewEncoder(f)
+				enc.SetIndent("", "  ")
+				_ = enc.Encode(data)
+			}
+			_ = zw.Close()
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(map[string]interface{}{
+			"exported_at": exportedAt,
+			"profile":     u,
+			"carts":       exported,
+			"trash":       trash,
+			"orders":      orders,
+			"budget":      budget.Monthly,
+			"lists":       exportedLists,
+			"templates":   exportedTemplates,
+		})
+	})
+}
+
+// Handlers
+func MakeAccountHandler(r Mux, auth *middleware.Auth, userSvc user.Service, cartSvc cart.Service, listSvc shoppinglist.Service) {
+	r.Handle("/api/user/delete", auth.Validate(deleteAccount(userSvc, cartSvc, listSvc)))
+	r.Handle("/api/user/export", auth.Validate(exportData(userSvc, cartSvc, listSvc)))
+}
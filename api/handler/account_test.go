@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/shoppinglist"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+// The export only reads, the fakes implement just the reads it makes and
+// panic on anything else through the nil interfaces they embed.
+
+type exportUsers struct{ user.Service }
+
+func (exportUsers) GetUserByUUID(id string) (*entities.User, error) {
+	return &entities.User{UUID: id, Name: "Ann", Email: "ann@example.com", Password: "$2a$10$hash"}, nil
+}
+
+type exportCarts struct{ cart.Service }
+
+func (exportCarts) GetCarts(userID string) (*[]entities.Cart, error) {
+	return &[]entities.Cart{{UUID: "c1", CartName: "Weekly", UserID: userID}}, nil
+}
+
+func (exportCarts) GetCartItems(cartID string) (*[]entities.CartItem, error) {
+	return &[]entities.CartItem{{UUID: "i1", CartID: cartID, ItemName: "Milk", ItemPrice: 120, ItemQuantity: 2}}, nil
+}
+
+func (exportCarts) GetTrash(userID string) (*[]entities.Cart, error) {
+	return &[]entities.Cart{}, nil
+}
+
+func (exportCarts) GetBudgetReport(cartID, userID string) (*cart.BudgetReport, error) {
+	return &cart.BudgetReport{}, nil
+}
+
+func (exportCarts) GetOrders(userID string) (*[]entities.Order, error) {
+	return &[]entities.Order{{UUID: "o1", UserID: userID, Total: 240}}, nil
+}
+
+func (exportCarts) GetTemplates(userID string) (*[]entities.CartTemplate, error) {
+	return &[]entities.CartTemplate{}, nil
+}
+
+type exportLists struct{ shoppinglist.Service }
+
+func (exportLists) GetLists(userID string) (*[]entities.ShoppingList, error) {
+	return &[]entities.ShoppingList{{UUID: "l1", UserID: userID, Name: "Party"}}, nil
+}
+
+func (exportLists) GetEntries(listID, userID string) (*[]entities.ShoppingListEntry, error) {
+	return &[]entities.ShoppingListEntry{{UUID: "e1", ListID: listID, Text: "Cake"}}, nil
+}
+
+func exportRequest(query string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/user/export"+query, nil)
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": "u1", "role": entities.RoleUser}}
+	return r.WithContext(middleware.WithToken(r.Context(), token))
+}
+
+func TestExportData(t *testing.T) {
+	w := httptest.NewRecorder()
+	exportData(exportUsers{}, exportCarts{}, exportLists{}).ServeHTTP(w, exportRequest(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") || !strings.Contains(cd, ".json") {
+		t.Errorf("Content-Disposition %q", cd)
+	}
+	if strings.Contains(w.Body.String(), "$2a$10$hash") {
+		t.Error("export contains the password hash")
+	}
+
+	var got struct {
+		Profile entities.User `json:"profile"`
+		Carts   []struct {
+			Items []entities.CartItem `json:"items"`
+		} `json:"carts"`
+		Orders []entities.Order `json:"orders"`
+		Lists  []struct {
+			Items []entities.ShoppingListEntry `json:"items"`
+		} `json:"lists"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Profile.Email != "ann@example.com" {
+		t.Errorf("profile %+v", got.Profile)
+	}
+	if len(got.Carts) != 1 || len(got.Carts[0].Items) != 1 || got.Carts[0].Items[0].ItemName != "Milk" {
+		t.Errorf("carts %+v", got.Carts)
+	}
+	if len(got.Orders) != 1 {
+		t.Errorf("orders %+v", got.Orders)
+	}
+	if len(got.Lists) != 1 || len(got.Lists[0].Items) != 1 || got.Lists[0].Items[0].Text != "Cake" {
+		t.Errorf("lists %+v", got.Lists)
+	}
+}
+
+func TestExportDataZip(t *testing.T) {
+	w := httptest.NewRecorder()
+	exportData(exportUsers{}, exportCarts{}, exportLists{}).ServeHTTP(w, exportRequest("?format=zip"))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if !json.Valid(data) {
+			t.Errorf("%s is not JSON: %s", f.Name, data)
+		}
+	}
+	sort.Strings(names)
+	want := "budget.json carts.json lists.json orders.json profile.json templates.json trash.json"
+	if strings.Join(names, " ") != want {
+		t.Errorf("files %v, want %s", names, want)
+	}
+}
+
+func TestExportDataNeedsToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/user/export", nil)
+	exportData(exportUsers{}, exportCarts{}, exportLists{}).ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", w.Code)
+	}
+}
+
+type deleteUsers struct {
+	user.Service
+	err error
+}
+
+func (u *deleteUsers) DeleteAccount(userUUID, password, ip string, removeData func(userID string) error) error {
+	if u.err != nil {
+		return u.err
+	}
+	return removeData(userUUID)
+}
+
+type deleteCarts struct {
+	cart.Service
+	deleted string
+}
+
+func (c *deleteCarts) DeleteUserData(userID string) error {
+	c.deleted = userID
+	return nil
+}
+
+type deleteLists struct {
+	shoppinglist.Service
+	deleted string
+}
+
+func (l *deleteLists) DeleteUserData(userID string) error {
+	l.deleted = userID
+	return nil
+}
+
+func TestDeleteAccount(t *testing.T) {
+	users, carts, lists := &deleteUsers{}, &deleteCarts{}, &deleteLists{}
+	r := exportRequest("")
+	r.Method = http.MethodPost
+	r.Body = ioutil.NopCloser(strings.NewReader(`{"password":"secret1"}`))
+	w := httptest.NewRecorder()
+	deleteAccount(users, carts, lists).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if carts.deleted != "u1" || lists.deleted != "u1" {
+		t.Errorf("carts of %q and lists of %q deleted, want u1", carts.deleted, lists.deleted)
+	}
+
+	users.err = pkg.ErrWrongPassword
+	r = exportRequest("")
+	r.Method = http.MethodPost
+	r.Body = ioutil.NopCloser(strings.NewReader(`{"password":"wrong"}`))
+	w = httptest.NewRecorder()
+	deleteAccount(users, carts, lists).ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong password: status %d, want 400", w.Code)
+	}
+}
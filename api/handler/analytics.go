@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/analytics"
+	"net/http"
+	"strconv"
+)
+
+func spendByMonth(svc analytics.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		result, err := svc.SpendByMonth(claims["id"].(string), q.Get("from"), q.Get("to"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Monthly Spend Fetched",
+			"months":  result,
+		})
+	})
+}
+
+func spendByStore(svc analytics.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		result, err := svc.SpendByStore(claims["id"].(string), q.Get("from"), q.Get("to"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Store Spend Fetched",
+			"stores":  result,
+		})
+	})
+}
+
+func spendByCategory(svc analytics.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		result, err := svc.SpendByCategory(claims["id"].(string), q.Get("from"), q.Get("to"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "Category Spend Fetched",
+			"categories": result,
+		})
+	})
+}
+
+func topProducts(svc analytics.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		limit := 0
+		if v := q.Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil {
+				view.Wrap(pkg.ErrLimit, w)
+				return
+			}
+		}
+
+		products, err := svc.TopProducts(claims["id"].(string), q.Get("from"), q.Get("to"), limit)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Top Products Fetched",
+			"products": products,
+		})
+	})
+}
+
+func basketStats(svc analytics.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		stats, err := svc.Basket(claims["id"].(string), q.Get("from"), q.Get("to"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Basket Stats Fetched",
+			"basket":  stats,
+		})
+	})
+}
+
+// Handlers
+func MakeAnalyticsHandler(r Mux, auth *middleware.Auth, svc analytics.Service) {
+	r.Handle("/api/analytics/monthly", auth.Validate(spendByMonth(svc)))
+	r.Handle("/api/analytics/stores", auth.Validate(spendByStore(svc)))
+	r.Handle("/api/analytics/categories", auth.Validate(spendByCategory(svc)))
+	r.Handle("/api/analytics/topproducts", auth.Validate(topProducts(svc)))
+	r.Handle("/api/analytics/basket", auth.Validate(basketStats(svc)))
+}
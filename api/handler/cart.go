@@ -3,10 +3,13 @@ package handler
 import (
 	"encoding/json"
 	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
 	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
 	"github.com/rithikjain/quickscan-backend/pkg/cart"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
 	"net/http"
+	"strconv"
 )
 
 func createCart(svc cart.Service) http.Handler {
@@ -16,20 +19,24 @@ func createCart(svc cart.Service) http.Handler {
 			return
 		}
 
-		var cart entities.Cart
-		if err := json.NewDecoder(r.Body).Decode(&cart); err != nil {
+		var req createCartRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		claims, err := middleware.ValidateAndGetClaims(r.Context(), "user")
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
-		cart.UserID = claims["id"].(string)
+		cart := entities.Cart{
+			CartName: req.CartName,
+			StoreID:  req.StoreID,
+			UserID:   claims["id"].(string),
+		}
 
-		c, err := svc.CreateCart(&cart)
+		c, err := svc.CreateCart(&cart, req.Location)
 		if err != nil {
 			view.Wrap(err, w)
 			return
@@ -51,12 +58,8 @@ func changeCartName(svc cart.Service) http.Handler {
 			return
 		}
 
-		type Req struct {
-			CartID  string `json:"cart_id"`
-			NewName string `json:"new_name"`
-		}
-		var req Req
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var req changeCartNameRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
@@ -83,13 +86,19 @@ func showMyCarts(svc cart.Service) http.Handler {
 			return
 		}
 
-		claims, err := middleware.ValidateAndGetClaims(r.Context(), "user")
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		opts, err := listOptions(r)
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		carts, err := svc.GetCarts(claims["id"].(string))
+		page, err := svc.ListCarts(claims["id"].(string), opts)
 		if err != nil {
 			view.Wrap(err, w)
 			return
@@ -98,8 +107,9 @@ func showMyCarts(svc cart.Service) http.Handler {
 		w.Header().Add("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Carts Fetched",
-			"carts":   carts,
+			"message":     "Carts Fetched",
+			"carts":       page.Carts,
+			"next_cursor": page.NextCursor,
 		})
 	})
 }
@@ -111,13 +121,13 @@ func createItem(svc cart.Service) http.Handler {
 			return
 		}
 
-		var cartItem entities.CartItem
-		if err := json.NewDecoder(r.Body).Decode(&cartItem); err != nil {
+		var req createItemRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		ci, err := svc.CreateCartItem(&cartItem)
+		ci, budget, err := svc.CreateCartItem(req.toCartItem(req.CartID))
 		if err != nil {
 			view.Wrap(err, w)
 			return
@@ -128,6 +138,7 @@ func createItem(svc cart.Service) http.Handler {
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Item Created",
 			"item":    ci,
+			"budget":  budget,
 		})
 	})
 }
@@ -139,17 +150,13 @@ func updateItemCount(svc cart.Service) http.Handler {
 			return
 		}
 
-		type Req struct {
-			ItemID   string `json:"item_id"`
-			NewCount int    `json:"new_count"`
-		}
-		var req Req
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var req updateItemCountRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		c, err := svc.UpdateCartItemCount(req.ItemID, req.NewCount)
+		c, budget, err := svc.UpdateCartItemCount(req.ItemID, req.NewCount)
 		if err != nil {
 			view.Wrap(err, w)
 			return
@@ -160,6 +167,7 @@ func updateItemCount(svc cart.Service) http.Handler {
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Cart Item Count Updated",
 			"item":    c,
+			"budget":  budget,
 		})
 	})
 }
@@ -171,11 +179,8 @@ func deleteItem(svc cart.Service) http.Handler {
 			return
 		}
 
-		type Req struct {
-			ItemID string `json:"item_id"`
-		}
-		var req Req
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var req itemIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
@@ -203,34 +208,585 @@ func showItems(svc cart.Service) http.Handler {
 
 		cartID := r.URL.Query().Get("cart_id")
 
-		items, err := svc.GetCartItems(cartID)
+		opts, err := listOptions(r)
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		var total = 0
-		for _, item := range *items {
-			total += item.ItemPrice * item.ItemQuantity
+		page, err := svc.ListCartItems(cartID, opts)
+		if err != nil {
+			view.Wrap(err, w)
+			return
 		}
 
 		w.Header().Add("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
 			"message":     "Items Fetched",
-			"items":       items,
-			"total_price": total,
+			"items":       page.Items,
+			"total_price": page.Total,
+			"next_cursor": page.NextCursor,
+		})
+	})
+}
+
+// listOptions reads the paging, sorting and filtering query parameters of
+// a listing.
+func listOptions(r *http.Request) (cart.ListOptions, error) {
+	q := r.URL.Query()
+	opts := cart.ListOptions{
+		Search:   q.Get("search"),
+		From:     q.Get("from"),
+		To:       q.Get("to"),
+		Archived: q.Get("archived"),
+		Sort:     q.Get("sort"),
+		Order:    q.Get("order"),
+		Cursor:   q.Get("cursor"),
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, pkg.ErrLimit
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+func checkout(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req cartIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		o, err := svc.Checkout(req.CartID, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Checkout Complete",
+			"order":   o,
+		})
+	})
+}
+
+func showMyOrders(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		orders, err := svc.GetOrders(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Orders Fetched",
+			"orders":  orders,
+		})
+	})
+}
+
+func saveTemplate(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req saveTemplateRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		t, err := svc.SaveAsTemplate(req.CartID, claims["id"].(string), req.Name)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Template Saved",
+			"template": t,
+		})
+	})
+}
+
+func showMyTemplates(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		templates, err := svc.GetTemplates(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Templates Fetched",
+			"templates": templates,
+		})
+	})
+}
+
+func showTemplate(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		t, err := svc.GetTemplate(r.URL.Query().Get("template_id"), claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Template Fetched",
+			"template": t,
+		})
+	})
+}
+
+func deleteTemplate(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req templateIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.DeleteTemplate(req.TemplateID, claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Template Deleted",
+		})
+	})
+}
+
+func cartFromTemplate(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req fromTemplateRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, changes, err := svc.CreateFromTemplate(req.TemplateID, claims["id"].(string), req.StoreID, req.Location)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Created",
+			"cart":    c,
+			"changes": changes,
+		})
+	})
+}
+
+func reorder(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req reorderRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, changes, err := svc.Reorder(req.OrderID, claims["id"].(string), req.StoreID, req.Location)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Created",
+			"cart":    c,
+			"changes": changes,
+		})
+	})
+}
+
+func archiveCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req archiveCartRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := svc.ArchiveCart(req.CartID, claims["id"].(string), req.Archived)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Updated",
+			"cart":    c,
+		})
+	})
+}
+
+func deleteCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req cartIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.DeleteCart(req.CartID, claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Moved To Trash",
+		})
+	})
+}
+
+func showTrash(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		carts, err := svc.GetTrash(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Trash Fetched",
+			"carts":   carts,
+		})
+	})
+}
+
+func restoreCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req cartIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := svc.RestoreCart(req.CartID, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Restored",
+			"cart":    c,
+		})
+	})
+}
+
+func purgeCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req cartIDRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.PurgeCart(req.CartID, claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Deleted Permanently",
+		})
+	})
+}
+
+func setCartBudget(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req cartBudgetRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := svc.SetCartBudget(req.CartID, claims["id"].(string), req.Budget)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Budget Updated",
+			"cart":    c,
+		})
+	})
+}
+
+func setMonthlyBudget(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req monthlyBudgetRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		b, err := svc.SetMonthlyBudget(claims["id"].(string), req.Budget, req.Timezone)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Budget Updated",
+			"budget":  b,
+		})
+	})
+}
+
+func showBudget(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		report, err := svc.GetBudgetReport(r.URL.Query().Get("cart_id"), claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Budget Fetched",
+			"budget":  report,
 		})
 	})
 }
 
 // Handler
-func MakeCartHandler(r *http.ServeMux, svc cart.Service) {
-	r.Handle("/api/cart/create", middleware.Validate(createCart(svc)))
-	r.Handle("/api/cart/changename", middleware.Validate(changeCartName(svc)))
-	r.Handle("/api/cart/showmycarts", middleware.Validate(showMyCarts(svc)))
-	r.Handle("/api/cart/additem", middleware.Validate(createItem(svc)))
-	r.Handle("/api/cart/updateitemcount", middleware.Validate(updateItemCount(svc)))
-	r.Handle("/api/cart/deleteitem", middleware.Validate(deleteItem(svc)))
+func MakeCartHandler(r Mux, auth *middleware.Auth, svc cart.Service) {
+	r.Handle("/api/cart/create", auth.Validate(createCart(svc)))
+	r.Handle("/api/cart/changename", auth.Validate(changeCartName(svc)))
+	r.Handle("/api/cart/showmycarts", auth.Validate(showMyCarts(svc)))
+	r.Handle("/api/cart/additem", auth.Validate(createItem(svc)))
+	r.Handle("/api/cart/updateitemcount", auth.Validate(updateItemCount(svc)))
+	r.Handle("/api/cart/deleteitem", auth.Validate(deleteItem(svc)))
 	r.Handle("/api/cart/showitems", showItems(svc))
+	r.Handle("/api/cart/checkout", auth.Validate(checkout(svc)))
+	r.Handle("/api/cart/showmyorders", auth.Validate(showMyOrders(svc)))
+	r.Handle("/api/cart/savetemplate", auth.Validate(saveTemplate(svc)))
+	r.Handle("/api/cart/showmytemplates", auth.Validate(showMyTemplates(svc)))
+	r.Handle("/api/cart/showtemplate", auth.Validate(showTemplate(svc)))
+	r.Handle("/api/cart/deletetemplate", auth.Validate(deleteTemplate(svc)))
+	r.Handle("/api/cart/fromtemplate", auth.Validate(cartFromTemplate(svc)))
+	r.Handle("/api/cart/reorder", auth.Validate(reorder(svc)))
+	r.Handle("/api/cart/archive", auth.Validate(archiveCart(svc)))
+	r.Handle("/api/cart/delete", auth.Validate(deleteCart(svc)))
+	r.Handle("/api/cart/showtrash", auth.Validate(showTrash(svc)))
+	r.Handle("/api/cart/restore", auth.Validate(restoreCart(svc)))
+	r.Handle("/api/cart/purge", auth.Validate(purgeCart(svc)))
+	r.Handle("/api/cart/setbudget", auth.Validate(setCartBudget(svc)))
+	r.Handle("/api/cart/setmonthlybudget", auth.Validate(setMonthlyBudget(svc)))
+	r.Handle("/api/cart/budget", auth.Validate(showBudget(svc)))
 }
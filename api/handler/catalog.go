@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg/catalog"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"net/http"
+)
+
+func lookupProduct(svc catalog.Service, stock inventory.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		storeID := r.URL.Query().Get("store_id")
+		barcode := r.URL.Query().Get("barcode")
+
+		p, err := svc.LookupBarcode(storeID, barcode)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		level, err := stock.GetStock(storeID, p.UUID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Product Found",
+			"product": p,
+			"stock":   level.Status(),
+		})
+	})
+}
+
+// Handler
+func MakeCatalogHandler(r Mux, svc catalog.Service, stock inventory.Service) {
+	r.Handle("/api/catalog/lookup", lookupProduct(svc, stock))
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rithikjain/quickscan-backend/api/graphql"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"net/http"
+)
+
+// Protected Request
+func graphQL(schema *graphqlgo.Schema, cartSvc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		var req graphQLRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		ctx := graphql.WithLoaders(r.Context(), cartSvc)
+		resp := schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+		graphql.FormatErrors(resp.Errors)
+
+		// Errors of single fields are part of a successful GraphQL
+		// response, so the status stays 200
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func MakeGraphQLHandler(r Mux, auth *middleware.Auth, userSvc user.Service, cartSvc cart.Service) {
+	schema := graphql.NewSchema(userSvc, cartSvc)
+	r.Handle("/graphql", auth.Validate(graphQL(schema, cartSvc)))
+}
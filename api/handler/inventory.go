@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"net/http"
+)
+
+// checkStaffStore lets admins manage every store and staff only the store
+// they work at.
+func checkStaffStore(claims map[string]interface{}, storeID string) error {
+	if claims["role"] == entities.RoleAdmin {
+		return nil
+	}
+	if assigned, _ := claims["store_id"].(string); assigned != "" && assigned == storeID {
+		return nil
+	}
+	return pkg.ErrForbidden
+}
+
+// Staff Request
+func stockDetails(svc inventory.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		if err := checkStaffStore(claims, q.Get("store_id")); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		stock, err := svc.GetStock(q.Get("store_id"), q.Get("product_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Stock Fetched",
+			"stock":   stock,
+			"status":  stock.Status(),
+		})
+	})
+}
+
+// Staff Request
+func restock(svc inventory.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			StoreID   string `json:"store_id"`
+			ProductID string `json:"product_id"`
+			Quantity  int    `json:"quantity"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := checkStaffStore(claims, req.StoreID); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		stock, err := svc.Restock(req.StoreID, req.ProductID, req.Quantity)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Stock Updated",
+			"stock":   stock,
+		})
+	})
+}
+
+// Staff Request
+func setLowStockThreshold(svc inventory.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			StoreID   string `json:"store_id"`
+			ProductID string `json:"product_id"`
+			Threshold int    `json:"threshold"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := checkStaffStore(claims, req.StoreID); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		stock, err := svc.SetThreshold(req.StoreID, req.ProductID, req.Threshold)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Threshold Updated",
+			"stock":   stock,
+		})
+	})
+}
+
+// Staff Request
+func lowStock(svc inventory.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := checkStaffStore(claims, r.URL.Query().Get("store_id")); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		stock, err := svc.GetLowStock(r.URL.Query().Get("store_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Low Stock Fetched",
+			"stock":   stock,
+		})
+	})
+}
+
+// Handler
+func MakeInventoryHandler(r Mux, auth *middleware.Auth, svc inventory.Service) {
+	r.Handle("/api/inventory/stock", auth.Validate(stockDetails(svc)))
+	r.Handle("/api/inventory/restock", auth.Validate(restock(svc)))
+	r.Handle("/api/inventory/threshold", auth.Validate(setLowStockThreshold(svc)))
+	r.Handle("/api/inventory/lowstock", auth.Validate(lowStock(svc)))
+}
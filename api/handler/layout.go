@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/layout"
+	"net/http"
+)
+
+// Staff Request
+func createZone(svc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		_, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var zone entities.StoreZone
+		if err := request.Decode(w, r, &zone); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		z, err := svc.CreateZone(&zone)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Zone Created",
+			"zone":    z,
+		})
+	})
+}
+
+// Staff Request
+func createAisle(svc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		_, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var aisle entities.Aisle
+		if err := request.Decode(w, r, &aisle); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		a, err := svc.CreateAisle(&aisle)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Aisle Created",
+			"aisle":   a,
+		})
+	})
+}
+
+// Staff Request
+func createBay(svc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		_, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var bay entities.Bay
+		if err := request.Decode(w, r, &bay); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		b, err := svc.CreateBay(&bay)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Bay Created",
+			"bay":     b,
+		})
+	})
+}
+
+// Staff Request
+func placeProduct(svc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		_, err := middleware.ValidateAndGetClaims(r.Context(), entities.RoleStaff, entities.RoleAdmin)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var placement entities.ProductPlacement
+		if err := request.Decode(w, r, &placement); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		p, err := svc.PlaceProduct(&placement)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Product Placed",
+			"placement": p,
+		})
+	})
+}
+
+func locateProduct(svc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		q := r.URL.Query()
+		l, err := svc.Locate(q.Get("store_id"), q.Get("product_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Product Located",
+			"location": l,
+		})
+	})
+}
+
+func cartRoute(svc layout.Service, cartSvc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		c, err := cartSvc.GetCart(r.URL.Query().Get("cart_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		if c.StoreID == "" {
+			view.Wrap(pkg.ErrNotAllowed, w)
+			return
+		}
+		items, err := cartSvc.GetCartItems(c.UUID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		productIDs := make([]string, len(*items))
+		for i, item := range *items {
+			productIDs[i] = item.ProductID
+		}
+		stops, err := svc.Route(c.StoreID, productIDs)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type RoutedItem struct {
+			entities.CartItem
+			Location *layout.Location `json:"location"`
+		}
+		route := make([]RoutedItem, len(stops))
+		for i, stop := range stops {
+			route[i] = RoutedItem{CartItem: (*items)[stop.Index], Location: stop.Location}
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Route Computed",
+			"items":   route,
+		})
+	})
+}
+
+// Handler
+func MakeLayoutHandler(r Mux, auth *middleware.Auth, svc layout.Service, cartSvc cart.Service) {
+	r.Handle("/api/layout/zone", auth.Validate(createZone(svc)))
+	r.Handle("/api/layout/aisle", auth.Validate(createAisle(svc)))
+	r.Handle("/api/layout/bay", auth.Validate(createBay(svc)))
+	r.Handle("/api/layout/placement", auth.Validate(placeProduct(svc)))
+	r.Handle("/api/layout/locate", locateProduct(svc))
+	r.Handle("/api/layout/cartroute", cartRoute(svc, cartSvc))
+}
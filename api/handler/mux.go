@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Mux is the part of http.ServeMux that handlers register their routes on.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// RouteRecorder passes registrations through to a Mux and remembers the
+// patterns, so the routes of a handler group can be listed afterwards.
+type RouteRecorder struct {
+	Mux
+	patterns []string
+}
+
+func NewRouteRecorder(m Mux) *RouteRecorder {
+	return &RouteRecorder{Mux: m}
+}
+
+func (r *RouteRecorder) Handle(pattern string, handler http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+	r.Mux.Handle(pattern, handler)
+}
+
+// Patterns returns the recorded patterns in sorted order.
+func (r *RouteRecorder) Patterns() []string {
+	patterns := append([]string(nil), r.patterns...)
+	sort.Strings(patterns)
+	return patterns
+}
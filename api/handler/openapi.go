@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/openapi"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+	swaggerFiles "github.com/swaggo/files"
+	"net/http"
+	"strconv"
+)
+
+// apiRoute documents one route. Request and response schemas come from the
+// same types the handler decodes and encodes, the response being the usual
+// envelope of a message plus the listed keys.
+type apiRoute struct {
+	method   string
+	path     string
+	tag      string
+	summary  string
+	auth     bool
+	query    []queryParam
+	body     interface{}
+	status   int
+	response map[string]interface{}
+}
+
+type queryParam struct {
+	name     string
+	required bool
+	// schema defaults to a plain string
+	schema *openapi.Schema
+}
+
+// listingParams are the query parameters read by listOptions.
+var listingParams = []queryParam{
+	{name: "search"},
+	{name: "from", schema: &openapi.Schema{Type: "string", Format: "date"}},
+	{name: "to", schema: &openapi.Schema{Type: "string", Format: "date"}},
+	{name: "sort", schema: &openapi.Schema{Type: "string", Enum: []string{cart.SortCreated, cart.SortUpdated, cart.SortName, cart.SortPrice}}},
+	{name: "order", schema: &openapi.Schema{Type: "string", Enum: []string{"asc", "desc"}}},
+	{name: "cursor"},
+	{name: "limit", schema: &openapi.Schema{Type: "integer", Minimum: &minLimit, Maximum: &maxLimit}},
+}
+
+var minLimit, maxLimit float64 = 1, 100
+
+// documentedRoutes lists the routes of MakeUserHandler and MakeCartHandler.
+// Startup fails when one of their routes is missing here.
+var documentedRoutes = []apiRoute{
+	{method: http.MethodPost, path: "/api/user/register", tag: "user", summary: "Create an account",
+		body: registerRequest{}, status: http.StatusCreated,
+		response: map[string]interface{}{"token": "", "user": entities.User{}}},
+	{method: http.MethodPost, path: "/api/user/login", tag: "user",
+		summary: "Log in with email and password. Accounts with two factor authentication get a challenge_token instead of a token",
+		body:    loginRequest{}, status: http.StatusOK,
+		response: map[string]interface{}{"token": "", "user": entities.User{}, "challenge_token": ""}},
+	{method: http.MethodPost, path: "/api/user/otp/request", tag: "user", summary: "Send a login code by SMS",
+		body: phoneOTPRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/user/otp/verify", tag: "user", summary: "Log in with an SMS code",
+		body: verifyOTPRequest{}, status: http.StatusOK,
+		response: map[string]interface{}{"token": "", "user": entities.User{}, "challenge_token": ""}},
+	{method: http.MethodGet, path: "/api/user/details", tag: "user", summary: "Get the logged in user", auth: true,
+		status: http.StatusOK, response: map[string]interface{}{"user": entities.User{}}},
+	{method: http.MethodPost, path: "/api/user/2fa/enroll", tag: "user", summary: "Start two factor authentication setup", auth: true,
+		status: http.StatusOK, response: map[string]interface{}{"secret": "", "otpauth_uri": "", "qr_code": ""}},
+	{method: http.MethodPost, path: "/api/user/2fa/confirm", tag: "user", summary: "Enable two factor authentication", auth: true,
+		body: totpCodeRequest{}, status: http.StatusOK, response: map[string]interface{}{"recovery_codes": []string{}}},
+	{method: http.MethodPost, path: "/api/user/2fa/disable", tag: "user", summary: "Disable two factor authentication", auth: true,
+		body: totpCodeRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/user/2fa/login", tag: "user", summary: "Exchange a login challenge and code for a token",
+		body: totpLoginRequest{}, status: http.StatusOK,
+		response: map[string]interface{}{"token": "", "user": entities.User{}}},
+	{method: http.MethodPost, path: "/api/user/update", tag: "user", summary: "Update the profile", auth: true,
+		body: user.ProfileUpdate{}, status: http.StatusOK, response: map[string]interface{}{"user": entities.User{}}},
+	{method: http.MethodPost, path: "/api/user/email/change", tag: "user", summary: "Send a verification link to a new email", auth: true,
+		body: changeEmailRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/user/email/confirm", tag: "user", summary: "Confirm an email change",
+		body: confirmEmailRequest{}, status: http.StatusOK, response: map[string]interface{}{"user": entities.User{}}},
+	{method: http.MethodPost, path: "/api/user/password/change", tag: "user", summary: "Change the password", auth: true,
+		body: changePasswordRequest{}, status: http.StatusOK},
+
+	{method: http.MethodPost, path: "/api/cart/create", tag: "cart", summary: "Start a cart", auth: true,
+		body: createCartRequest{}, status: http.StatusCreated, response: map[string]interface{}{"cart": entities.Cart{}}},
+	{method: http.MethodPost, path: "/api/cart/changename", tag: "cart", summary: "Rename a cart", auth: true,
+		body: changeCartNameRequest{}, status: http.StatusOK, response: map[string]interface{}{"cart": entities.Cart{}}},
+	{method: http.MethodGet, path: "/api/cart/showmycarts", tag: "cart", summary: "List the user's carts", auth: true,
+		query: append([]queryParam{{name: "archived", schema: &openapi.Schema{Type: "boolean"}}}, listingParams...), status: http.StatusOK,
+		response: map[string]interface{}{"carts": []entities.Cart{}, "next_cursor": ""}},
+	{method: http.MethodPost, path: "/api/cart/additem", tag: "cart", summary: "Add an item to a cart", auth: true,
+		body: createItemRequest{}, status: http.StatusCreated,
+		response: map[string]interface{}{"item": entities.CartItem{}, "budget": cart.BudgetReport{}}},
+	{method: http.MethodPost, path: "/api/cart/updateitemcount", tag: "cart", summary: "Change the quantity of an item", auth: true,
+		body: updateItemCountRequest{}, status: http.StatusOK,
+		response: map[string]interface{}{"item": entities.CartItem{}, "budget": cart.BudgetReport{}}},
+	{method: http.MethodPost, path: "/api/cart/deleteitem", tag: "cart", summary: "Remove an item from a cart", auth: true,
+		body: itemIDRequest{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/cart/showitems", tag: "cart", summary: "List the items of a cart",
+		query: append([]queryParam{{name: "cart_id", required: true}}, listingParams...), status: http.StatusOK,
+		response: map[string]interface{}{"items": []entities.CartItem{}, "total_price": 0, "next_cursor": ""}},
+	{method: http.MethodPost, path: "/api/cart/checkout", tag: "cart", summary: "Check out a cart", auth: true,
+		body: cartIDRequest{}, status: http.StatusCreated, response: map[string]interface{}{"order": entities.Order{}}},
+	{method: http.MethodGet, path: "/api/cart/showmyorders", tag: "cart", summary: "List the user's orders", auth: true,
+		status: http.StatusOK, response: map[string]interface{}{"orders": []entities.Order{}}},
+	{method: http.MethodPost, path: "/api/cart/savetemplate", tag: "cart", summary: "Save a cart as a template", auth: true,
+		body: saveTemplateRequest{}, status: http.StatusCreated, response: map[string]interface{}{"template": cart.TemplateDetails{}}},
+	{method: http.MethodGet, path: "/api/cart/showmytemplates", tag: "cart", summary: "List the user's templates", auth: true,
+		status: http.StatusOK, response: map[string]interface{}{"templates": []entities.CartTemplate{}}},
+	{method: http.MethodGet, path: "/api/cart/showtemplate", tag: "cart", summary: "Get a template with its items", auth: true,
+		query: []queryParam{{name: "template_id", required: true}}, status: http.StatusOK,
+		response: map[string]interface{}{"template": cart.TemplateDetails{}}},
+	{method: http.MethodPost, path: "/api/cart/deletetemplate", tag: "cart", summary: "Delete a template", auth: true,
+		body: templateIDRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/cart/fromtemplate", tag: "cart", summary: "Start a cart from a template", auth: true,
+		body: fromTemplateRequest{}, status: http.StatusCreated,
+		response: map[string]interface{}{"cart": entities.Cart{}, "changes": []cart.LineChange{}}},
+	{method: http.MethodPost, path: "/api/cart/reorder", tag: "cart", summary: "Start a cart from a previous order", auth: true,
+		body: reorderRequest{}, status: http.StatusCreated,
+		response: map[string]interface{}{"cart": entities.Cart{}, "changes": []cart.LineChange{}}},
+	{method: http.MethodPost, path: "/api/cart/archive", tag: "cart", summary: "Archive or unarchive a cart", auth: true,
+		body: archiveCartRequest{}, status: http.StatusOK, response: map[string]interface{}{"cart": entities.Cart{}}},
+	{method: http.MethodPost, path: "/api/cart/delete", tag: "cart", summary: "Move a cart to the trash", auth: true,
+		body: cartIDRequest{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/cart/showtrash", tag: "cart", summary: "List the carts in the trash", auth: true,
+		status: http.StatusOK, response: map[string]interface{}{"carts": []entities.Cart{}}},
+	{method: http.MethodPost, path: "/api/cart/restore", tag: "cart", summary: "Restore a cart from the trash", auth: true,
+		body: cartIDRequest{}, status: http.StatusOK, response: map[string]interface{}{"cart": entities.Cart{}}},
+	{method: http.MethodPost, path: "/api/cart/purge", tag: "cart", summary: "Permanently delete a cart in the trash", auth: true,
+		body: cartIDRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/cart/setbudget", tag: "cart", summary: "Set the budget of a cart", auth: true,
+		body: cartBudgetRequest{}, status: http.StatusOK, response: map[string]interface{}{"cart": entities.Cart{}}},
+	{method: http.MethodPost, path: "/api/cart/setmonthlybudget", tag: "cart", summary: "Set the monthly budget", auth: true,
+		body: monthlyBudgetRequest{}, status: http.StatusOK, response: map[string]interface{}{"budget": entities.MonthlyBudget{}}},
+	{method: http.MethodGet, path: "/api/cart/budget", tag: "cart", summary: "Get the monthly budget and optionally a cart's budget", auth: true,
+		query: []queryParam{{name: "cart_id"}}, status: http.StatusOK,
+		response: map[string]interface{}{"budget": cart.BudgetReport{}}},
+}
+
+// OpenAPISpec builds the OpenAPI document of the documented routes.
+func OpenAPISpec() *openapi.Document {
+	doc := openapi.New("QwikScan API", "1.0.0")
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+	}
+	doc.Components.Responses["Problem"] = &openapi.Response{
+		Description: "Error, as RFC 7807 problem details",
+		Content: map[string]openapi.MediaType{
+			"application/problem+json": {Schema: doc.SchemaOf(view.Problem{})},
+		},
+	}
+
+	for _, route := range documentedRoutes {
+		op := &openapi.Operation{
+			Summary: route.summary,
+			Tags:    []string{route.tag},
+			Responses: map[string]*openapi.Response{
+				"default": {Ref: "#/components/responses/Problem"},
+			},
+		}
+		if route.auth {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+		for _, q := range route.query {
+			schema := q.schema
+			if schema == nil {
+				schema = &openapi.Schema{Type: "string"}
+			}
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     q.name,
+				In:       "query",
+				Required: q.required,
+				Schema:   schema,
+			})
+		}
+		if route.body != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: doc.SchemaOf(route.body)},
+				},
+			}
+		}
+
+		props := map[string]*openapi.Schema{"message": {Type: "string"}}
+		for key, v := range route.response {
+			props[key] = doc.SchemaOf(v)
+		}
+		op.Responses[strconv.Itoa(route.status)] = &openapi.Response{
+			Description: http.StatusText(route.status),
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: openapi.Object(props, "message")},
+			},
+		}
+		doc.Add(route.method, route.path, op)
+	}
+	return doc
+}
+
+// docsPage renders the spec with Swagger UI, whose assets are served from
+// the binary so the docs work without reaching a CDN.
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>QwikScan API</title>
+  <link rel="stylesheet" href="/api/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/api/docs/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: "/api/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+func openAPIDocument(doc *openapi.Document) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+}
+
+func docsUI() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(docsPage))
+	})
+}
+
+// docsAssets serves the Swagger UI files bundled by swaggerFiles. Its own
+// index page is replaced by ours.
+func docsAssets() http.Handler {
+	files := http.StripPrefix("/api/docs", http.FileServer(swaggerFiles.HTTP))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/docs/" || r.URL.Path == "/api/docs/index.html" {
+			docsUI().ServeHTTP(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// Handlers
+func MakeDocsHandler(r Mux, doc *openapi.Document) {
+	r.Handle("/api/openapi.json", openAPIDocument(doc))
+	r.Handle("/api/docs", docsUI())
+	r.Handle("/api/docs/", docsAssets())
+}
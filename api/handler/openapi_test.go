@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/pkg/config"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAPISpecCoversDocumentedRoutes(t *testing.T) {
+	auth := middleware.NewAuth(config.JWT{Secret: "0123456789abcdef0123456789abcdef", TTL: time.Hour}, nil)
+	documented := NewRouteRecorder(http.NewServeMux())
+	MakeUserHandler(documented, auth, nil)
+	MakeCartHandler(documented, auth, nil)
+
+	if missing := OpenAPISpec().Missing(documented.Patterns()); len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI spec: %v", missing)
+	}
+}
+
+func TestOpenAPISpecHidesSecrets(t *testing.T) {
+	u := OpenAPISpec().Components.Schemas["User"]
+	if u == nil {
+		t.Fatal("no User schema")
+	}
+	for _, secret := range []string{"password", "TOTPSecret", "TOTPLastStep"} {
+		if _, ok := u.Properties[secret]; ok {
+			t.Errorf("User schema exposes %s", secret)
+		}
+	}
+}
+
+func TestUserJSONHidesSecrets(t *testing.T) {
+	b, err := json.Marshal(entities.User{Password: "$2a$10$hash", TOTPSecret: "SECRET"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"$2a$10$hash", "SECRET", "password"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("user JSON contains %q: %s", secret, b)
+		}
+	}
+}
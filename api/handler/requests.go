@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+// Request payloads are decoded into these types rather than into entities,
+// so that clients cannot set IDs, owners or other server managed fields.
+// Rules are declared in validate tags, see the request package.
+
+type registerRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Email       string `json:"email" validate:"required,email,max=254"`
+	Password    string `json:"password" validate:"required,min=6,max=60"`
+	PhoneNumber string `json:"phone_number" validate:"max=32"`
+	ImageUrl    string `json:"image_url" validate:"max=2048"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required,max=60"`
+}
+
+type phoneOTPRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,max=32"`
+}
+
+type verifyOTPRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,max=32"`
+	Code        string `json:"code" validate:"required,max=16"`
+}
+
+type totpLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required,max=128"`
+	Code           string `json:"code" validate:"required,max=32"`
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type changeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email,max=254"`
+}
+
+type confirmEmailRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=60"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=60"`
+}
+
+type createCartRequest struct {
+	CartName string           `json:"cart_name" validate:"required,max=100"`
+	StoreID  string           `json:"store_id" validate:"uuid"`
+	Location *entities.LatLng `json:"location"`
+}
+
+type changeCartNameRequest struct {
+	CartID  string `json:"cart_id" validate:"required,uuid"`
+	NewName string `json:"new_name" validate:"required,max=100"`
+}
+
+type cartIDRequest struct {
+	CartID string `json:"cart_id" validate:"required,uuid"`
+}
+
+type archiveCartRequest struct {
+	CartID   string `json:"cart_id" validate:"required,uuid"`
+	Archived bool   `json:"archived"`
+}
+
+type cartBudgetRequest struct {
+	CartID string `json:"cart_id" validate:"required,uuid"`
+	Budget int    `json:"budget" validate:"min=0,max=100000000"`
+}
+
+type monthlyBudgetRequest struct {
+	Budget   int    `json:"budget" validate:"min=0,max=100000000"`
+	Timezone string `json:"timezone" validate:"max=64"`
+}
+
+// itemFields are the item fields a client may send. Items linked to a
+// catalog product take their name and price from the catalog.
+type itemFields struct {
+	ProductID    string `json:"product_id" validate:"uuid"`
+	ItemName     string `json:"item_name" validate:"max=200"`
+	ItemPrice    int    `json:"item_price" validate:"min=0,max=100000000"`
+	ItemQuantity int    `json:"item_quantity" validate:"required,min=1,max=1000"`
+	ItemImageUrl string `json:"item_image_url" validate:"max=2048"`
+}
+
+func (f itemFields) toCartItem(cartID string) *entities.CartItem {
+	return &entities.CartItem{
+		CartID:       cartID,
+		ProductID:    f.ProductID,
+		ItemName:     f.ItemName,
+		ItemPrice:    f.ItemPrice,
+		ItemQuantity: f.ItemQuantity,
+		ItemImageUrl: f.ItemImageUrl,
+	}
+}
+
+type createItemRequest struct {
+	CartID string `json:"cart_id" validate:"required,uuid"`
+	itemFields
+}
+
+type updateItemCountRequest struct {
+	ItemID   string `json:"item_id" validate:"required,uuid"`
+	NewCount int    `json:"new_count" validate:"required,min=1,max=1000"`
+}
+
+type itemIDRequest struct {
+	ItemID string `json:"item_id" validate:"required,uuid"`
+}
+
+type saveTemplateRequest struct {
+	CartID string `json:"cart_id" validate:"required,uuid"`
+	Name   string `json:"name" validate:"required,max=100"`
+}
+
+type templateIDRequest struct {
+	TemplateID string `json:"template_id" validate:"required,uuid"`
+}
+
+type fromTemplateRequest struct {
+	TemplateID string           `json:"template_id" validate:"required,uuid"`
+	StoreID    string           `json:"store_id" validate:"uuid"`
+	Location   *entities.LatLng `json:"location"`
+}
+
+type reorderRequest struct {
+	OrderID  string           `json:"order_id" validate:"required,uuid"`
+	StoreID  string           `json:"store_id" validate:"uuid"`
+	Location *entities.LatLng `json:"location"`
+}
+
+type v2CreateCartRequest struct {
+	CartName string           `json:"cart_name" validate:"required,max=100"`
+	StoreID  string           `json:"store_id" validate:"uuid"`
+	Budget   int              `json:"budget" validate:"min=0,max=100000000"`
+	Location *entities.LatLng `json:"location"`
+}
+
+type v2UpdateCartRequest struct {
+	CartName *string `json:"cart_name" validate:"min=1,max=100"`
+	Budget   *int    `json:"budget" validate:"min=0,max=100000000"`
+	Archived *bool   `json:"archived"`
+}
+
+type v2UpdateItemRequest struct {
+	ItemQuantity int `json:"item_quantity" validate:"required,min=1,max=1000"`
+}
+
+// graphQLRequest is the body of a GraphQL request as sent by common
+// clients over HTTP.
+type graphQLRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/layout"
+	"github.com/rithikjain/quickscan-backend/pkg/shoppinglist"
+	"net/http"
+)
+
+func createList(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			Name   string `json:"name"`
+			Active bool   `json:"active"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		l, err := svc.CreateList(&entities.ShoppingList{
+			UserID: claims["id"].(string),
+			Name:   req.Name,
+			Active: req.Active,
+		})
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List Created",
+			"list":    l,
+		})
+	})
+}
+
+func showMyLists(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		lists, err := svc.GetLists(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Lists Fetched",
+			"lists":   lists,
+		})
+	})
+}
+
+func showList(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		userID := claims["id"].(string)
+
+		l, err := svc.GetList(r.URL.Query().Get("list_id"), userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		entries, err := svc.GetEntries(l.UUID, userID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List Fetched",
+			"list":    l,
+			"items":   entries,
+		})
+	})
+}
+
+func activateList(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			ListID string `json:"list_id"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		l, err := svc.SetActive(req.ListID, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List Activated",
+			"list":    l,
+		})
+	})
+}
+
+func deleteList(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			ListID string `json:"list_id"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.DeleteList(req.ListID, claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List Deleted",
+		})
+	})
+}
+
+func addListEntry(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			ListID    string `json:"list_id"`
+			ProductID string `json:"product_id"`
+			Text      string `json:"text"`
+			Quantity  int    `json:"quantity"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		e, err := svc.AddEntry(&entities.ShoppingListEntry{
+			ListID:    req.ListID,
+			ProductID: req.ProductID,
+			Text:      req.Text,
+			Quantity:  req.Quantity,
+		}, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Added",
+			"item":    e,
+		})
+	})
+}
+
+func checkListEntry(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			ItemID  string `json:"item_id"`
+			Checked bool   `json:"checked"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		e, err := svc.SetChecked(req.ItemID, claims["id"].(string), req.Checked)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Updated",
+			"item":    e,
+		})
+	})
+}
+
+func deleteListEntry(svc shoppinglist.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type Req struct {
+			ItemID string `json:"item_id"`
+		}
+		var req Req
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.RemoveEntry(req.ItemID, claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Deleted",
+		})
+	})
+}
+
+// missingItems matches a cart against a list, the user's active list
+// unless list_id is given.
+func missingItems(svc shoppinglist.Service, cartSvc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		userID := claims["id"].(string)
+
+		q := r.URL.Query()
+		listID := q.Get("list_id")
+		if listID == "" {
+			l, err := svc.GetActiveList(userID)
+			if err != nil {
+				view.Wrap(err, w)
+				return
+			}
+			listID = l.UUID
+		}
+
+		c, err := cartSvc.GetCart(q.Get("cart_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		if c.UserID != userID {
+			view.Wrap(pkg.ErrForbidden, w)
+			return
+		}
+		items, err := cartSvc.GetCartItems(c.UUID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		result, err := svc.Match(listID, userID, *items)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List Matched",
+			"result":  result,
+		})
+	})
+}
+
+func listRoute(svc shoppinglist.Service, layoutSvc layout.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		q := r.URL.Query()
+		entries, err := svc.GetEntries(q.Get("list_id"), claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		productIDs := make([]string, len(*entries))
+		for i, e := range *entries {
+			productIDs[i] = e.ProductID
+		}
+		stops, err := layoutSvc.Route(q.Get("store_id"), productIDs)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		type RoutedEntry struct {
+			entities.ShoppingListEntry
+			Location *layout.Location `json:"location"`
+		}
+		route := make([]RoutedEntry, len(stops))
+		for i, stop := range stops {
+			route[i] = RoutedEntry{ShoppingListEntry: (*entries)[stop.Index], Location: stop.Location}
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Route Computed",
+			"items":   route,
+		})
+	})
+}
+
+// Handler
+func MakeShoppingListHandler(r Mux, auth *middleware.Auth, svc shoppinglist.Service, cartSvc cart.Service, layoutSvc layout.Service) {
+	r.Handle("/api/shoppinglist/create", auth.Validate(createList(svc)))
+	r.Handle("/api/shoppinglist/showmylists", auth.Validate(showMyLists(svc)))
+	r.Handle("/api/shoppinglist/show", auth.Validate(showList(svc)))
+	r.Handle("/api/shoppinglist/activate", auth.Validate(activateList(svc)))
+	r.Handle("/api/shoppinglist/delete", auth.Validate(deleteList(svc)))
+	r.Handle("/api/shoppinglist/additem", auth.Validate(addListEntry(svc)))
+	r.Handle("/api/shoppinglist/checkitem", auth.Validate(checkListEntry(svc)))
+	r.Handle("/api/shoppinglist/deleteitem", auth.Validate(deleteListEntry(svc)))
+	r.Handle("/api/shoppinglist/missing", auth.Validate(missingItems(svc, cartSvc)))
+	r.Handle("/api/shoppinglist/route", auth.Validate(listRoute(svc, layoutSvc)))
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/store"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func listStores(svc store.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		stores, err := svc.GetStores()
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Stores Fetched",
+			"stores":  stores,
+		})
+	})
+}
+
+func storeDetails(svc store.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		s, err := svc.GetStore(r.URL.Query().Get("store_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Store Found",
+			"store":   s,
+		})
+	})
+}
+
+func nearbyStores(svc store.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		q := r.URL.Query()
+		lat, err := strconv.ParseFloat(q.Get("lat"), 64)
+		if err != nil {
+			view.Wrap(pkg.ErrCoordinates, w)
+			return
+		}
+		lng, err := strconv.ParseFloat(q.Get("lng"), 64)
+		if err != nil {
+			view.Wrap(pkg.ErrCoordinates, w)
+			return
+		}
+		var radius float64
+		if q.Get("radius") != "" {
+			radius, err = strconv.ParseFloat(q.Get("radius"), 64)
+			if err != nil {
+				view.Wrap(pkg.ErrCoordinates, w)
+				return
+			}
+		}
+
+		stores, err := svc.NearbyStores(entities.LatLng{Latitude: lat, Longitude: lng}, radius)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Stores Fetched",
+			"stores":  stores,
+		})
+	})
+}
+
+func storeHours(svc store.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		st, err := svc.GetStore(r.URL.Query().Get("store_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		hours, err := svc.GetHours(st.UUID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		// The upcoming holidays start from today where the store is
+		loc, err := time.LoadLocation(st.Timezone)
+		if err != nil {
+			view.Wrap(pkg.ErrTimezone, w)
+			return
+		}
+		today := time.Now().In(loc)
+		holidays, err := svc.GetHolidays(
+			st.UUID,
+			today.Format("2006-01-02"),
+			today.AddDate(0, 0, 30).Format("2006-01-02"),
+		)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Opening Hours Fetched",
+			"timezone": st.Timezone,
+			"hours":    hours,
+			"holidays": holidays,
+		})
+	})
+}
+
+func storeOpenStatus(svc store.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		st, err := svc.GetStore(r.URL.Query().Get("store_id"))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		status, err := svc.OpenStatus(st, time.Now())
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Store Status Fetched",
+			"status":  status,
+		})
+	})
+}
+
+// Handler
+func MakeStoreHandler(r Mux, svc store.Service) {
+	r.Handle("/api/store/list", listStores(svc))
+	r.Handle("/api/store/details", storeDetails(svc))
+	r.Handle("/api/store/nearby", nearbyStores(svc))
+	r.Handle("/api/store/hours", storeHours(svc))
+	r.Handle("/api/store/isopen", storeOpenStatus(svc))
+}
@@ -1,26 +1,53 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"github.com/dgrijalva/jwt-go"
 	uuid2 "github.com/nu7hatch/gouuid"
 	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
 	"github.com/rithikjain/quickscan-backend/api/view"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
 	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"github.com/skip2/go-qrcode"
 	"net/http"
-	"os"
 )
 
-func register(svc user.Service) http.Handler {
+func issueToken(auth *middleware.Auth, u *entities.User) (string, error) {
+	role := u.Role
+	if role == "" {
+		role = entities.RoleUser
+	}
+	claims := jwt.MapClaims{
+		"id":   u.UUID,
+		"role": role,
+	}
+	if role == entities.RoleStaff {
+		claims["store_id"] = u.StoreID
+	}
+	return auth.IssueToken(claims)
+}
+
+// writeChallenge tells the client that a TOTP code is needed to finish
+// logging in.
+func writeChallenge(w http.ResponseWriter, challenge string) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":         "Two Factor Authentication Required",
+		"challenge_token": challenge,
+	})
+}
+
+func register(svc user.Service, auth *middleware.Auth) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			view.Wrap(view.ErrMethodNotAllowed, w)
 			return
 		}
 
-		var user entities.User
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		var req registerRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
@@ -30,7 +57,14 @@ func register(svc user.Service) http.Handler {
 			view.Wrap(err, w)
 			return
 		}
-		user.UUID = uuid.String()
+		user := entities.User{
+			UUID:        uuid.String(),
+			Name:        req.Name,
+			Email:       req.Email,
+			Password:    req.Password,
+			PhoneNumber: req.PhoneNumber,
+			ImageUrl:    req.ImageUrl,
+		}
 
 		u, err := svc.Register(&user)
 		if err != nil {
@@ -39,16 +73,11 @@ func register(svc user.Service) http.Handler {
 		}
 
 		// Handling JWT
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"id":   u.UUID,
-			"role": "user",
-		})
-		tokenString, err := token.SignedString([]byte(os.Getenv("jwt_secret")))
+		tokenString, err := issueToken(auth, u)
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
-		u.Password = ""
 		w.Header().Add("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
@@ -59,34 +88,128 @@ func register(svc user.Service) http.Handler {
 	})
 }
 
-func login(svc user.Service) http.Handler {
+func login(svc user.Service, auth *middleware.Auth) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			view.Wrap(view.ErrMethodNotAllowed, w)
 			return
 		}
-		var user entities.User
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		var req loginRequest
+		if err := request.Decode(w, r, &req); err != nil {
 			view.Wrap(err, w)
 			return
 		}
 
-		u, err := svc.Login(user.Email, user.Password)
+		u, challenge, err := svc.Login(req.Email, req.Password, middleware.ClientIP(r))
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
+		if challenge != "" {
+			writeChallenge(w, challenge)
+			return
+		}
+
+		tokenString, err := issueToken(auth, u)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Login Successful",
+			"token":   tokenString,
+			"user":    u,
+		})
+	})
+}
+
+func requestOTP(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		var req phoneOTPRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"id":   u.UUID,
-			"role": "user",
+		if err := svc.RequestPhoneOTP(req.PhoneNumber); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Code Sent",
+		})
+	})
+}
+
+func verifyOTP(svc user.Service, auth *middleware.Auth) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		var req verifyOTPRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, challenge, err := svc.VerifyPhoneOTP(req.PhoneNumber, req.Code)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		if challenge != "" {
+			writeChallenge(w, challenge)
+			return
+		}
+
+		tokenString, err := issueToken(auth, u)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Login Successful",
+			"token":   tokenString,
+			"user":    u,
 		})
-		tokenString, err := token.SignedString([]byte(os.Getenv("jwt_secret")))
+	})
+}
+
+func totpLogin(svc user.Service, auth *middleware.Auth) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		var req totpLoginRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, err := svc.CompleteTOTPLogin(req.ChallengeToken, req.Code)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		tokenString, err := issueToken(auth, u)
 		if err != nil {
 			view.Wrap(err, w)
 			return
 		}
-		u.Password = ""
 		w.Header().Add("Content-Type", "application/json; charset=utf-8")
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Login Successful",
@@ -96,6 +219,108 @@ func login(svc user.Service) http.Handler {
 	})
 }
 
+// Protected Request
+func enrollTOTP(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		secret, uri, err := svc.EnrollTOTP(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":     "Scan The QR Code And Confirm With A Code",
+			"secret":      secret,
+			"otpauth_uri": uri,
+			"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		})
+	})
+}
+
+// Protected Request
+func confirmTOTP(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req totpCodeRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		codes, err := svc.ConfirmTOTP(claims["id"].(string), req.Code)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":        "Two Factor Authentication Enabled",
+			"recovery_codes": codes,
+		})
+	})
+}
+
+// Protected Request
+func disableTOTP(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req totpCodeRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.DisableTOTP(claims["id"].(string), req.Code); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Two Factor Authentication Disabled",
+		})
+	})
+}
+
 // Protected Request
 func userDetails(svc user.Service) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -104,7 +329,7 @@ func userDetails(svc user.Service) http.Handler {
 			return
 		}
 
-		claims, err := middleware.ValidateAndGetClaims(r.Context(), "user")
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
 		if err != nil {
 			view.Wrap(err, w)
 			return
@@ -122,9 +347,143 @@ func userDetails(svc user.Service) http.Handler {
 	})
 }
 
+// Protected Request
+func updateProfile(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req user.ProfileUpdate
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, err := svc.UpdateProfile(claims["id"].(string), &req)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Profile Updated",
+			"user":    u,
+		})
+	})
+}
+
+// Protected Request
+func changeEmail(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req changeEmailRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.RequestEmailChange(claims["id"].(string), req.NewEmail); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Verification Sent To New Email",
+		})
+	})
+}
+
+func confirmEmail(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		var req confirmEmailRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, err := svc.ConfirmEmailChange(req.Token)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Email Updated",
+			"user":    u,
+		})
+	})
+}
+
+// Protected Request
+func changePassword(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			view.Wrap(view.ErrMethodNotAllowed, w)
+			return
+		}
+
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req changePasswordRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		err = svc.ChangePassword(claims["id"].(string), req.CurrentPassword, req.NewPassword, middleware.ClientIP(r))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Password Changed",
+		})
+	})
+}
+
 // Handlers
-func MakeUserHandler(r *http.ServeMux, svc user.Service) {
-	r.Handle("/api/user/register", register(svc))
-	r.Handle("/api/user/login", login(svc))
-	r.Handle("/api/user/details", middleware.Validate(userDetails(svc)))
+func MakeUserHandler(r Mux, auth *middleware.Auth, svc user.Service) {
+	r.Handle("/api/user/register", register(svc, auth))
+	r.Handle("/api/user/login", login(svc, auth))
+	r.Handle("/api/user/otp/request", requestOTP(svc))
+	r.Handle("/api/user/otp/verify", verifyOTP(svc, auth))
+	r.Handle("/api/user/details", auth.Validate(userDetails(svc)))
+	r.Handle("/api/user/2fa/enroll", auth.Validate(enrollTOTP(svc)))
+	r.Handle("/api/user/2fa/confirm", auth.Validate(confirmTOTP(svc)))
+	r.Handle("/api/user/2fa/disable", auth.Validate(disableTOTP(svc)))
+	r.Handle("/api/user/2fa/login", totpLogin(svc, auth))
+	r.Handle("/api/user/update", auth.Validate(updateProfile(svc)))
+	r.Handle("/api/user/email/change", auth.Validate(changeEmail(svc)))
+	r.Handle("/api/user/email/confirm", confirmEmail(svc))
+	r.Handle("/api/user/password/change", auth.Validate(changePassword(svc)))
 }
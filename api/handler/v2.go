@@ -0,0 +1,490 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"net/http"
+)
+
+// The v2 API exposes the same services as v1 as resources. Routing on the
+// method is left to the router, so handlers here do not check r.Method.
+
+// ownedCart fetches the cart named in the path and checks that it belongs
+// to the caller.
+func ownedCart(svc cart.Service, r *http.Request, userID string) (*entities.Cart, error) {
+	c, err := svc.GetCart(mux.Vars(r)["cartId"])
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	return c, nil
+}
+
+// ownedItem fetches the item named in the path and checks that it is part
+// of the caller's cart named in the path.
+func ownedItem(svc cart.Service, r *http.Request, userID string) (*entities.CartItem, error) {
+	c, err := ownedCart(svc, r, userID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := svc.GetCartItem(mux.Vars(r)["itemId"])
+	if err != nil {
+		return nil, err
+	}
+	if item.CartID != c.UUID {
+		return nil, pkg.ErrNotFound
+	}
+	return item, nil
+}
+
+// Protected Request
+func v2GetMe(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, err := svc.GetUserByUUID(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "User Found",
+			"user":    u,
+		})
+	})
+}
+
+// Protected Request
+func v2UpdateMe(svc user.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req user.ProfileUpdate
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		u, err := svc.UpdateProfile(claims["id"].(string), &req)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Profile Updated",
+			"user":    u,
+		})
+	})
+}
+
+// Protected Request
+func v2ListCarts(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		carts, err := svc.GetCarts(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Carts Fetched",
+			"carts":   carts,
+		})
+	})
+}
+
+// Protected Request
+func v2CreateCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req v2CreateCartRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := svc.CreateCart(&entities.Cart{
+			CartName: req.CartName,
+			StoreID:  req.StoreID,
+			UserID:   claims["id"].(string),
+			Budget:   req.Budget,
+		}, req.Location)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Location", fmt.Sprintf("/api/v2/carts/%s", c.UUID))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Created",
+			"cart":    c,
+		})
+	})
+}
+
+// Protected Request
+func v2GetCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := ownedCart(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Fetched",
+			"cart":    c,
+		})
+	})
+}
+
+// Protected Request
+func v2UpdateCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		userID := claims["id"].(string)
+
+		var req v2UpdateCartRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := svc.UpdateCart(mux.Vars(r)["cartId"], userID, &cart.CartUpdate{
+			CartName: req.CartName,
+			Budget:   req.Budget,
+			Archived: req.Archived,
+		})
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Cart Updated",
+			"cart":    c,
+		})
+	})
+}
+
+// Protected Request
+func v2DeleteCart(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		if err := svc.DeleteCart(mux.Vars(r)["cartId"], claims["id"].(string)); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Protected Request
+func v2Checkout(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		order, err := svc.Checkout(mux.Vars(r)["cartId"], claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Location", fmt.Sprintf("/api/v2/orders/%s", order.UUID))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Checked Out",
+			"order":   order,
+		})
+	})
+}
+
+// Protected Request
+func v2ListItems(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := ownedCart(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		items, err := svc.GetCartItems(c.UUID)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Items Fetched",
+			"items":   items,
+		})
+	})
+}
+
+// Protected Request
+func v2CreateItem(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req itemFields
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		c, err := ownedCart(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		item, budget, err := svc.CreateCartItem(req.toCartItem(c.UUID))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Location", fmt.Sprintf("/api/v2/carts/%s/items/%s", c.UUID, item.UUID))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Created",
+			"item":    item,
+			"budget":  budget,
+		})
+	})
+}
+
+// Protected Request
+func v2GetItem(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		item, err := ownedItem(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Fetched",
+			"item":    item,
+		})
+	})
+}
+
+// Protected Request
+func v2UpdateItem(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		var req v2UpdateItemRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		item, err := ownedItem(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		item, budget, err := svc.UpdateCartItemCount(item.UUID, req.ItemQuantity)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Item Updated",
+			"item":    item,
+			"budget":  budget,
+		})
+	})
+}
+
+// Protected Request
+func v2DeleteItem(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		item, err := ownedItem(svc, r, claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		if err := svc.DeleteCartItem(item.UUID); err != nil {
+			view.Wrap(err, w)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Protected Request
+func v2ListOrders(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		orders, err := svc.GetOrders(claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Orders Fetched",
+			"orders":  orders,
+		})
+	})
+}
+
+// Protected Request
+func v2GetOrder(svc cart.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middleware.ValidateAndGetClaims(r.Context(), middleware.AllRoles...)
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		order, err := svc.GetOrder(mux.Vars(r)["orderId"], claims["id"].(string))
+		if err != nil {
+			view.Wrap(err, w)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Order Fetched",
+			"order":   order,
+		})
+	})
+}
+
+// Handlers
+func MakeV2Handler(r Mux, auth *middleware.Auth, userSvc user.Service, cartSvc cart.Service) {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		view.Wrap(pkg.ErrNotFound, w)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		view.Wrap(view.ErrMethodNotAllowed, w)
+	})
+
+	v2 := router.PathPrefix("/api/v2").Subrouter()
+	v2.Handle("/users/me", v2GetMe(userSvc)).Methods(http.MethodGet)
+	v2.Handle("/users/me", v2UpdateMe(userSvc)).Methods(http.MethodPatch)
+
+	v2.Handle("/carts", v2ListCarts(cartSvc)).Methods(http.MethodGet)
+	v2.Handle("/carts", v2CreateCart(cartSvc)).Methods(http.MethodPost)
+	v2.Handle("/carts/{cartId}", v2GetCart(cartSvc)).Methods(http.MethodGet)
+	v2.Handle("/carts/{cartId}", v2UpdateCart(cartSvc)).Methods(http.MethodPatch)
+	v2.Handle("/carts/{cartId}", v2DeleteCart(cartSvc)).Methods(http.MethodDelete)
+	v2.Handle("/carts/{cartId}/checkout", v2Checkout(cartSvc)).Methods(http.MethodPost)
+
+	v2.Handle("/carts/{cartId}/items", v2ListItems(cartSvc)).Methods(http.MethodGet)
+	v2.Handle("/carts/{cartId}/items", v2CreateItem(cartSvc)).Methods(http.MethodPost)
+	v2.Handle("/carts/{cartId}/items/{itemId}", v2GetItem(cartSvc)).Methods(http.MethodGet)
+	v2.Handle("/carts/{cartId}/items/{itemId}", v2UpdateItem(cartSvc)).Methods(http.MethodPatch)
+	v2.Handle("/carts/{cartId}/items/{itemId}", v2DeleteItem(cartSvc)).Methods(http.MethodDelete)
+
+	v2.Handle("/orders", v2ListOrders(cartSvc)).Methods(http.MethodGet)
+	v2.Handle("/orders/{orderId}", v2GetOrder(cartSvc)).Methods(http.MethodGet)
+
+	r.Handle("/api/v2/", auth.Validate(router))
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ClientIPs finds the address of the caller of each request, see ClientIP.
+type ClientIPs struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPs trusts X-Forwarded-For from the given addresses and CIDR
+// ranges, which config.Load has checked to be valid.
+func NewClientIPs(proxies []string) *ClientIPs {
+	c := &ClientIPs{}
+	for _, p := range proxies {
+		if _, n, err := net.ParseCIDR(p); err == nil {
+			c.trusted = append(c.trusted, n)
+		} else if ip := net.ParseIP(p); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			c.trusted = append(c.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return c
+}
+
+func (c *ClientIPs) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range c.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP walks X-Forwarded-For from the right as long as the hops are
+// trusted proxies. The first untrusted hop is the client, anything left of
+// it was supplied by the client and cannot be believed.
+func (c *ClientIPs) clientIP(r *http.Request) string {
+	ip := remoteHost(r)
+	if !c.isTrusted(ip) {
+		return ip
+	}
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Handler stores the address of the caller in the request context.
+func (c *ClientIPs) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey{}, c.clientIP(r))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIP returns the address of the caller as found by ClientIPs.Handler,
+// or the remote address of the connection outside of it.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -6,24 +6,119 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/rithikjain/quickscan-backend/api/view"
 	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/config"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
 	"log"
 	"net/http"
-	"os"
+	"time"
 )
 
-func Validate(h http.Handler) http.Handler {
+// tokenKey is where the parsed token is kept in the request context, the
+// default property of jwtmiddleware.
+const tokenKey = "user"
+
+// AccessChecker checks the claims of a token against the account it was
+// issued for, user.Service implements it.
+type AccessChecker interface {
+	CheckAccess(userUUID, role, storeID string) error
+}
+
+// Auth issues and checks the JWTs of the APIs.
+type Auth struct {
+	secret []byte
+	ttl    time.Duration
+	users  AccessChecker
+}
+
+// NewAuth returns an Auth signing with the secret of cfg, which
+// config.Load has checked to be set. Every token is checked against users
+// so that disabled accounts and changed roles take effect right away.
+func NewAuth(cfg config.JWT, users AccessChecker) *Auth {
+	return &Auth{secret: []byte(cfg.Secret), ttl: cfg.TTL, users: users}
+}
+
+func (a *Auth) validationKey(token *jwt.Token) (interface{}, error) {
+	return a.secret, nil
+}
+
+// IssueToken signs the claims, which expire after the configured TTL.
+func (a *Auth) IssueToken(claims jwt.MapClaims) (string, error) {
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(a.ttl).Unix()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+}
+
+func (a *Auth) Validate(h http.Handler) http.Handler {
 	jwtMiddleware := jwtmiddleware.New(jwtmiddleware.Options{
-		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("jwt_secret")), nil
+		ValidationKeyGetter: a.validationKey,
+		SigningMethod:       jwt.SigningMethodHS256,
+		UserProperty:        tokenKey,
+		// Missing and invalid tokens get the same problem response as
+		// every other error
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err string) {
+			view.Wrap(view.ErrInvalidToken, w)
 		},
-		SigningMethod: jwt.SigningMethodHS256,
 	})
 
-	return jwtMiddleware.Handler(h)
+	return jwtMiddleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// OPTIONS requests are let through without a token
+		if token, ok := r.Context().Value(tokenKey).(*jwt.Token); ok {
+			if err := a.check(token); err != nil {
+				view.Wrap(err, w)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	}))
+}
+
+// check rejects tokens without an expiry, which were issued before tokens
+// expired, and asks users whether the account still has the claimed access.
+func (a *Auth) check(token *jwt.Token) error {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return view.ErrInvalidToken
+	}
+	id, _ := claims["id"].(string)
+	role, _ := claims["role"].(string)
+	storeID, _ := claims["store_id"].(string)
+	if id == "" {
+		return view.ErrInvalidToken
+	}
+	return a.users.CheckAccess(id, role, storeID)
 }
 
-func ValidateAndGetClaims(ctx context.Context, role string) (map[string]interface{}, error) {
-	token, ok := ctx.Value("user").(*jwt.Token)
+// ParseToken checks a raw bearer token the way Validate does, for
+// transports that do not go through net/http.
+func (a *Auth) ParseToken(raw string) (*jwt.Token, error) {
+	token, err := jwt.Parse(raw, a.validationKey)
+	if err != nil || !token.Valid {
+		return nil, view.ErrInvalidToken
+	}
+	if token.Header["alg"] != jwt.SigningMethodHS256.Alg() {
+		return nil, view.ErrInvalidToken
+	}
+	if err := a.check(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// WithToken stores a token parsed by ParseToken where
+// ValidateAndGetClaims finds it.
+func WithToken(ctx context.Context, token *jwt.Token) context.Context {
+	return context.WithValue(ctx, tokenKey, token)
+}
+
+// AllRoles lets every signed in user through, for the endpoints that act on
+// the caller's own data. Staff and admins shop like everyone else.
+var AllRoles = []string{entities.RoleUser, entities.RoleStaff, entities.RoleAdmin}
+
+// ValidateAndGetClaims returns the claims of the request's token when its
+// role is one of roles.
+func ValidateAndGetClaims(ctx context.Context, roles ...string) (map[string]interface{}, error) {
+	token, ok := ctx.Value(tokenKey).(*jwt.Token)
 	if !ok {
 		log.Println(token)
 		return nil, view.ErrInvalidToken
@@ -40,9 +135,12 @@ func ValidateAndGetClaims(ctx context.Context, role string) (map[string]interfac
 		return nil, view.ErrInvalidToken
 	}
 
-	if claims["role"].(string) != role {
-		log.Println(claims["role"])
-		return nil, pkg.ErrUnauthorized
+	role, _ := claims["role"].(string)
+	for _, r := range roles {
+		if r == role {
+			return claims, nil
+		}
 	}
-	return claims, nil
+	log.Println(claims["role"])
+	return nil, pkg.ErrUnauthorized
 }
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/config"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type checker struct{ err error }
+
+func (c checker) CheckAccess(userUUID, role, storeID string) error {
+	return c.err
+}
+
+func TestValidate(t *testing.T) {
+	cfg := config.JWT{Secret: "secret", TTL: time.Hour}
+	valid, err := NewAuth(cfg, nil).IssueToken(jwt.MapClaims{"id": "u1", "role": entities.RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	noExpiry, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "u1", "role": entities.RoleUser}).SignedString([]byte("secret"))
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "u1", "exp": time.Now().Add(-time.Minute).Unix()}).SignedString([]byte("secret"))
+	otherKey, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "u1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("other"))
+
+	tests := []struct {
+		name   string
+		token  string
+		access error
+		want   int
+	}{
+		{"valid", valid, nil, http.StatusOK},
+		{"no token", "", nil, http.StatusUnauthorized},
+		{"no expiry", noExpiry, nil, http.StatusUnauthorized},
+		{"expired", expired, nil, http.StatusUnauthorized},
+		{"other key", otherKey, nil, http.StatusUnauthorized},
+		{"disabled", valid, pkg.ErrDisabled, http.StatusForbidden},
+		{"stale", valid, pkg.ErrStaleToken, http.StatusUnauthorized},
+		{"database down", valid, pkg.ErrDatabase, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			h := NewAuth(cfg, checker{tt.access}).Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want || reached != (tt.want == http.StatusOK) {
+				t.Errorf("status %d, handler reached %v, want %d", w.Code, reached, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	cfg := config.JWT{Secret: "secret", TTL: time.Hour}
+	raw, err := NewAuth(cfg, nil).IssueToken(jwt.MapClaims{"id": "u1", "role": entities.RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAuth(cfg, checker{}).ParseToken(raw); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+	if _, err := NewAuth(cfg, checker{pkg.ErrDatabase}).ParseToken(raw); err != pkg.ErrDatabase {
+		t.Errorf("database down: %v, want ErrDatabase", err)
+	}
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"id": "u1"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := NewAuth(cfg, checker{}).ParseToken(none); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
@@ -0,0 +1,73 @@
+// Package request decodes and validates handler payloads.
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodyBytes caps the size of JSON request bodies.
+const MaxBodyBytes = 1 << 20
+
+// Decode reads the JSON body of r into dst and validates it. Unknown
+// fields, trailing data and bodies over MaxBodyBytes are rejected. Every
+// invalid field is reported at once in a *pkg.ValidationError.
+func Decode(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	body := http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return pkg.ErrBody
+	}
+	return Validate(dst)
+}
+
+// decodeError turns the errors of encoding/json into errors the view knows
+// how to report.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return &pkg.ValidationError{Fields: []pkg.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("must be a %s", jsonType(typeErr.Type.Kind().String())),
+		}}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &pkg.ValidationError{Fields: []pkg.FieldError{{
+			Field:   field,
+			Code:    "unknown_field",
+			Message: "is not a known field",
+		}}}
+	case err.Error() == "http: request body too large":
+		return pkg.ErrBodyTooLarge
+	default:
+		return pkg.ErrBody
+	}
+}
+
+// jsonType names a Go kind the way it appears in JSON.
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "struct", kind == "map", kind == "ptr":
+		return "object"
+	default:
+		return kind
+	}
+}
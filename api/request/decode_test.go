@@ -0,0 +1,80 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+)
+
+type decodeRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Count int    `json:"count"`
+	Inner struct {
+		Flag bool `json:"flag"`
+	} `json:"inner"`
+}
+
+func decode(body string) error {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	var dst decodeRequest
+	return Decode(httptest.NewRecorder(), r, &dst)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// err is matched with errors.Is, field and code describe a
+		// validation error
+		err         error
+		field, code string
+	}{
+		{"valid", `{"name":"Ann","count":2,"inner":{"flag":true}}`, nil, "", ""},
+		{"unknown field", `{"name":"Ann","colour":"red"}`, pkg.ErrValidation, "colour", "unknown_field"},
+		{"wrong type", `{"name":"Ann","count":"two"}`, pkg.ErrValidation, "count", "invalid_type"},
+		{"wrong nested type", `{"name":"Ann","inner":{"flag":1}}`, pkg.ErrValidation, "inner.flag", "invalid_type"},
+		{"validated", `{"count":2}`, pkg.ErrValidation, "name", "required"},
+		{"trailing data", `{"name":"Ann"} {"name":"Bob"}`, pkg.ErrBody, "", ""},
+		{"not json", `name=Ann`, pkg.ErrBody, "", ""},
+		{"truncated", `{"name":"Ann"`, pkg.ErrBody, "", ""},
+		{"empty", ``, pkg.ErrBody, "", ""},
+		{"too large", `{"name":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, pkg.ErrBodyTooLarge, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decode(tt.body)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("Decode = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Decode = %v, want %v", err, tt.err)
+			}
+			if tt.field == "" {
+				return
+			}
+			var v *pkg.ValidationError
+			if !errors.As(err, &v) || len(v.Fields) != 1 || v.Fields[0].Field != tt.field || v.Fields[0].Code != tt.code {
+				t.Errorf("Decode = %+v, want %s %s", err, tt.field, tt.code)
+			}
+		})
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	tests := map[string]string{
+		"int": "number", "uint8": "number", "float64": "number",
+		"bool": "boolean", "slice": "array", "map": "object", "struct": "object", "string": "string",
+	}
+	for kind, want := range tests {
+		if got := jsonType(kind); got != want {
+			t.Errorf("jsonType(%q) = %q, want %q", kind, got, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package request
+
+import (
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rules are declared in a `validate` struct tag as a comma separated list:
+//
+//	required      the value must not be the zero value, or blank for strings
+//	min=N, max=N  bounds on numbers, or on the length of strings
+//	email         the string must look like an email address
+//	uuid          the string must be a UUID
+//	oneof=a b     the string must be one of the space separated values
+//
+// Rules other than required are skipped for empty values. Pointer fields
+// are optional: nil means absent, while a value that is present is always
+// checked, so `validate:"min=1"` on a *string rejects "". Nested structs
+// are validated with their fields reported as parent.child, and embedded
+// structs as if their fields were declared in the parent.
+
+var (
+	emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidRegexp  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Checker is implemented by requests with rules that span several fields.
+// Check runs after the tag rules.
+type Checker interface {
+	Check() []pkg.FieldError
+}
+
+// Validate checks v, a struct or pointer to one, against its validate tags
+// and returns a *pkg.ValidationError listing every invalid field.
+func Validate(v interface{}) error {
+	var fields []pkg.FieldError
+	validateStruct(reflect.ValueOf(v), "", &fields)
+	if c, ok := v.(Checker); ok {
+		fields = append(fields, c.Check()...)
+	}
+	if len(fields) > 0 {
+		return &pkg.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, fields *[]pkg.FieldError) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			validateStruct(v.Field(i), prefix, fields)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+
+		value := v.Field(i)
+		pointer := value.Kind() == reflect.Ptr
+		present := !pointer || !value.IsNil()
+		if pointer && present {
+			value = value.Elem()
+		}
+
+		if rules := f.Tag.Get("validate"); rules != "" {
+			empty := !present || (!pointer && isEmpty(value))
+			if fe := check(name, value, empty, rules); fe != nil {
+				*fields = append(*fields, *fe)
+				continue
+			}
+		}
+		if present && value.Kind() == reflect.Struct {
+			validateStruct(value, name+".", fields)
+		}
+	}
+}
+
+// check applies rules to one value and returns the first rule it breaks.
+func check(name string, v reflect.Value, empty bool, rules string) *pkg.FieldError {
+	for _, rule := range strings.Split(rules, ",") {
+		key, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, arg = rule[:i], rule[i+1:]
+		}
+
+		if key == "required" {
+			if empty {
+				return &pkg.FieldError{Field: name, Code: "required", Message: "is required"}
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+
+		switch key {
+		case "min", "max":
+			bound, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("request: bad %s rule on %s", key, name))
+			}
+			n, isString := size(v)
+			if key == "min" && n < bound {
+				if isString && bound == 1 {
+					return &pkg.FieldError{Field: name, Code: "too_short", Message: "must not be empty"}
+				}
+				if isString {
+					return &pkg.FieldError{Field: name, Code: "too_short", Message: fmt.Sprintf("must be at least %s characters", arg)}
+				}
+				return &pkg.FieldError{Field: name, Code: "too_small", Message: fmt.Sprintf("must be at least %s", arg)}
+			}
+			if key == "max" && n > bound {
+				if isString {
+					return &pkg.FieldError{Field: name, Code: "too_long", Message: fmt.Sprintf("must be at most %s characters", arg)}
+				}
+				return &pkg.FieldError{Field: name, Code: "too_large", Message: fmt.Sprintf("must be at most %s", arg)}
+			}
+		case "email":
+			if !emailRegexp.MatchString(v.String()) {
+				return &pkg.FieldError{Field: name, Code: "invalid_email", Message: "must be an email address"}
+			}
+		case "uuid":
+			if !uuidRegexp.MatchString(v.String()) {
+				return &pkg.FieldError{Field: name, Code: "invalid_uuid", Message: "must be a UUID"}
+			}
+		case "oneof":
+			found := false
+			for _, allowed := range strings.Fields(arg) {
+				if v.String() == allowed {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return &pkg.FieldError{Field: name, Code: "not_allowed", Message: fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(arg), ", "))}
+			}
+		default:
+			panic(fmt.Sprintf("request: unknown rule %q on %s", key, name))
+		}
+	}
+	return nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) == ""
+	}
+	return v.IsZero()
+}
+
+// size is the value compared by min and max, the rune count for strings.
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), false
+	}
+	return 0, false
+}
+
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "" {
+		return f.Name
+	}
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "" {
+		return f.Name
+	}
+	return tag
+}
@@ -0,0 +1,155 @@
+package request
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type base struct {
+	StoreID string `json:"store_id" validate:"uuid"`
+}
+
+type testRequest struct {
+	Name     string   `json:"name" validate:"required,max=5"`
+	Email    string   `json:"email" validate:"email"`
+	Age      int      `json:"age" validate:"min=18,max=130"`
+	Role     string   `json:"role" validate:"oneof=user admin"`
+	Nickname *string  `json:"nickname" validate:"min=1"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Address  *address `json:"address"`
+	Home     address  `json:"home"`
+	Ignored  string   `json:"-" validate:"required"`
+	private  string
+}
+
+// codes returns the field and code of every error in err.
+func codes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return map[string]string{}
+	}
+	var v *pkg.ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("error %v is not a *pkg.ValidationError", err)
+	}
+	if !errors.Is(err, pkg.ErrValidation) {
+		t.Errorf("%v does not match ErrValidation", err)
+	}
+	got := map[string]string{}
+	for _, f := range v.Fields {
+		got[f.Field] = f.Code
+	}
+	return got
+}
+
+func TestValidate(t *testing.T) {
+	empty, nickname := "", "Al"
+	valid := testRequest{Name: "Ann", Home: address{City: "Leeds"}}
+	tests := []struct {
+		name string
+		edit func(r *testRequest)
+		want map[string]string
+	}{
+		{"valid", func(r *testRequest) {}, map[string]string{}},
+		{"everything set", func(r *testRequest) {
+			r.Email, r.Age, r.Role, r.Nickname, r.Tags = "ann@example.com", 30, "admin", &nickname, []string{"a"}
+			r.Address = &address{City: "York"}
+		}, map[string]string{}},
+		{"required", func(r *testRequest) { r.Name = "" }, map[string]string{"name": "required"}},
+		{"blank is empty", func(r *testRequest) { r.Name = "   " }, map[string]string{"name": "required"}},
+		{"max counts runes", func(r *testRequest) { r.Name = "Zoë's" }, map[string]string{}},
+		{"too long", func(r *testRequest) { r.Name = "Annabel" }, map[string]string{"name": "too_long"}},
+		{"email", func(r *testRequest) { r.Email = "ann@example" }, map[string]string{"email": "invalid_email"}},
+		{"too small", func(r *testRequest) { r.Age = 17 }, map[string]string{"age": "too_small"}},
+		{"too large", func(r *testRequest) { r.Age = 131 }, map[string]string{"age": "too_large"}},
+		{"oneof", func(r *testRequest) { r.Role = "root" }, map[string]string{"role": "not_allowed"}},
+		{"present pointer is checked", func(r *testRequest) { r.Nickname = &empty }, map[string]string{"nickname": "too_short"}},
+		{"slice length", func(r *testRequest) { r.Tags = []string{"a", "b", "c"} }, map[string]string{"tags": "too_large"}},
+		{"nested pointer", func(r *testRequest) { r.Address = &address{} }, map[string]string{"address.city": "required"}},
+		{"nested struct", func(r *testRequest) { r.Home.City = "" }, map[string]string{"home.city": "required"}},
+		{"every field at once", func(r *testRequest) { r.Name, r.Age, r.Role = "", 5, "x" }, map[string]string{"name": "required", "age": "too_small", "role": "not_allowed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.edit(&r)
+			if got := codes(t, Validate(&r)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type embedding struct {
+	base
+	Name string `json:"name" validate:"required"`
+}
+
+type checked struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+func (c checked) Check() []pkg.FieldError {
+	if c.From > c.To {
+		return []pkg.FieldError{{Field: "to", Code: "before_from", Message: "must not be before from"}}
+	}
+	return nil
+}
+
+func TestValidateUUID(t *testing.T) {
+	type req struct {
+		ID string `json:"id" validate:"uuid"`
+	}
+	if err := Validate(req{ID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}); err != nil {
+		t.Errorf("valid UUID: %v", err)
+	}
+	if got := codes(t, Validate(req{ID: "6ba7b810"})); got["id"] != "invalid_uuid" {
+		t.Errorf("short UUID: %v", got)
+	}
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	got := codes(t, Validate(&embedding{base: base{StoreID: "x"}}))
+	want := map[string]string{"store_id": "invalid_uuid", "name": "required"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate = %v, want %v", got, want)
+	}
+}
+
+func TestValidateChecker(t *testing.T) {
+	if err := Validate(checked{From: 1, To: 2}); err != nil {
+		t.Errorf("valid range: %v", err)
+	}
+	if got := codes(t, Validate(checked{From: 3, To: 2})); got["to"] != "before_from" {
+		t.Errorf("reversed range: %v", got)
+	}
+}
+
+func TestValidatePanicsOnBadRules(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			A string `validate:"shiny"`
+		}{A: "x"},
+		&struct {
+			A int `validate:"min=one"`
+		}{A: 1},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Validate(%+v) did not panic", v)
+				}
+			}()
+			Validate(v)
+		}()
+	}
+}
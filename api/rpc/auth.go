@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"context"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// authenticate checks the bearer token in the "authorization" metadata and
+// stores it in the context, where middleware.ValidateAndGetClaims reads the
+// claims from just like it does for HTTP requests.
+func authenticate(ctx context.Context, auth *middleware.Auth) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Error: Authorization token is required")
+	}
+	raw := strings.TrimSpace(values[0])
+	if len(raw) < 7 || !strings.EqualFold(raw[:7], "bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "Error: Authorization header format must be Bearer {token}")
+	}
+
+	// Disabled accounts get PermissionDenied like over HTTP
+	token, err := auth.ParseToken(strings.TrimSpace(raw[7:]))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return middleware.WithToken(ctx, token), nil
+}
+
+func unaryAuth(auth *middleware.Auth) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+func streamAuth(auth *middleware.Auth) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth)
+		if err != nil {
+			return err
+		}
+		if err := handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx}); err != nil {
+			return toStatus(ctx, err)
+		}
+		return nil
+	}
+}
+
+// authenticatedStream hands the authenticated context to stream handlers.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
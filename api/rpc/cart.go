@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"context"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/request"
+	"github.com/rithikjain/quickscan-backend/api/rpc/pb"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type cartServer struct {
+	carts  cart.Service
+	events *cart.Events
+}
+
+// accessibleCart fetches the cart when the caller owns it or is staff.
+func (s *cartServer) accessibleCart(ctx context.Context, cartID string) (*entities.Cart, error) {
+	claims, err := middleware.ValidateAndGetClaims(ctx, middleware.AllRoles...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.carts.GetCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if claims["role"] == entities.RoleUser && c.UserID != claims["id"] {
+		return nil, pkg.ErrForbidden
+	}
+	return c, nil
+}
+
+func (s *cartServer) GetCart(ctx context.Context, req *pb.GetCartRequest) (*pb.Cart, error) {
+	c, err := s.accessibleCart(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toCart(c), nil
+}
+
+func (s *cartServer) ListCarts(ctx context.Context, req *pb.ListCartsRequest) (*pb.ListCartsResponse, error) {
+	claims, err := middleware.ValidateAndGetClaims(ctx, middleware.AllRoles...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.carts.ListCarts(claims["id"].(string), toListOptions(req.Options))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListCartsResponse{NextCursor: page.NextCursor}
+	for i := range page.Carts {
+		resp.Carts = append(resp.Carts, toCart(&page.Carts[i]))
+	}
+	return resp, nil
+}
+
+type createCartInput struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	StoreID string `json:"store_id" validate:"uuid"`
+}
+
+func (s *cartServer) CreateCart(ctx context.Context, req *pb.CreateCartRequest) (*pb.Cart, error) {
+	claims, err := middleware.ValidateAndGetClaims(ctx, middleware.AllRoles...)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(createCartInput{Name: req.Name, StoreID: req.StoreId}); err != nil {
+		return nil, err
+	}
+
+	var at *entities.LatLng
+	if req.Location != nil {
+		at = &entities.LatLng{Latitude: req.Location.Latitude, Longitude: req.Location.Longitude}
+	}
+	c, err := s.carts.CreateCart(&entities.Cart{
+		CartName: req.Name,
+		StoreID:  req.StoreId,
+		UserID:   claims["id"].(string),
+	}, at)
+	if err != nil {
+		return nil, err
+	}
+	return toCart(c), nil
+}
+
+func (s *cartServer) ListItems(ctx context.Context, req *pb.ListItemsRequest) (*pb.ListItemsResponse, error) {
+	if _, err := s.accessibleCart(ctx, req.CartId); err != nil {
+		return nil, err
+	}
+	page, err := s.carts.ListCartItems(req.CartId, toListOptions(req.Options))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListItemsResponse{NextCursor: page.NextCursor, TotalPrice: int64(page.Total)}
+	for i := range page.Items {
+		resp.Items = append(resp.Items, toCartItem(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+type addItemInput struct {
+	ProductID string `json:"product_id" validate:"uuid"`
+	Name      string `json:"name" validate:"max=200"`
+	Price     int64  `json:"price" validate:"min=0,max=100000000"`
+	Quantity  int64  `json:"quantity" validate:"required,min=1,max=1000"`
+	ImageUrl  string `json:"image_url" validate:"max=2048"`
+}
+
+func (s *cartServer) AddItem(ctx context.Context, req *pb.AddItemRequest) (*pb.CartItem, error) {
+	in := addItemInput{
+		ProductID: req.ProductId,
+		Name:      req.Name,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		ImageUrl:  req.ImageUrl,
+	}
+	if err := request.Validate(in); err != nil {
+		return nil, err
+	}
+	if _, err := s.accessibleCart(ctx, req.CartId); err != nil {
+		return nil, err
+	}
+
+	item, _, err := s.carts.CreateCartItem(&entities.CartItem{
+		CartID:       req.CartId,
+		ProductID:    in.ProductID,
+		ItemName:     in.Name,
+		ItemPrice:    int(in.Price),
+		ItemQuantity: int(in.Quantity),
+		ItemImageUrl: in.ImageUrl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCartItem(item), nil
+}
+
+type updateItemCountInput struct {
+	Count int64 `json:"count" validate:"required,min=1,max=1000"`
+}
+
+func (s *cartServer) UpdateItemCount(ctx context.Context, req *pb.UpdateItemCountRequest) (*pb.CartItem, error) {
+	if err := request.Validate(updateItemCountInput{Count: req.Count}); err != nil {
+		return nil, err
+	}
+	item, err := s.carts.GetCartItem(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.accessibleCart(ctx, item.CartID); err != nil {
+		return nil, err
+	}
+
+	item, _, err = s.carts.UpdateCartItemCount(req.Id, int(req.Count))
+	if err != nil {
+		return nil, err
+	}
+	return toCartItem(item), nil
+}
+
+func (s *cartServer) RemoveItem(ctx context.Context, req *pb.RemoveItemRequest) (*pb.RemoveItemResponse, error) {
+	item, err := s.carts.GetCartItem(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.accessibleCart(ctx, item.CartID); err != nil {
+		return nil, err
+	}
+
+	if err := s.carts.DeleteCartItem(req.Id); err != nil {
+		return nil, err
+	}
+	return &pb.RemoveItemResponse{}, nil
+}
+
+// Checkout checks the cart out on behalf of its owner, so staff at a till
+// can complete a customer's cart.
+func (s *cartServer) Checkout(ctx context.Context, req *pb.CheckoutRequest) (*pb.Order, error) {
+	c, err := s.accessibleCart(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.carts.Checkout(c.UUID, c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(order), nil
+}
+
+func (s *cartServer) WatchCart(req *pb.WatchCartRequest, stream pb.CartService_WatchCartServer) error {
+	ctx := stream.Context()
+	if _, err := s.accessibleCart(ctx, req.CartId); err != nil {
+		return err
+	}
+
+	events, cancel := s.events.Subscribe(req.CartId)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toCartEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/rithikjain/quickscan-backend/api/rpc/pb"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"time"
+)
+
+func toTimestamp(t time.Time) *timestamp.Timestamp {
+	ts, _ := ptypes.TimestampProto(t)
+	return ts
+}
+
+func toOptionalTimestamp(t *time.Time) *timestamp.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return toTimestamp(*t)
+}
+
+// toUser leaves out the password and TOTP secret.
+func toUser(u *entities.User) *pb.User {
+	return &pb.User{
+		Id:          u.UUID,
+		Name:        u.Name,
+		Email:       u.Email,
+		PhoneNumber: u.PhoneNumber,
+		ImageUrl:    u.ImageUrl,
+		Role:        u.Role,
+		TotpEnabled: u.TOTPEnabled,
+		CreatedAt:   toTimestamp(u.CreatedAt),
+	}
+}
+
+func toCart(c *entities.Cart) *pb.Cart {
+	return &pb.Cart{
+		Id:           c.UUID,
+		Name:         c.CartName,
+		UserId:       c.UserID,
+		StoreId:      c.StoreID,
+		Budget:       int64(c.Budget),
+		CreatedAt:    toTimestamp(c.CreatedAt),
+		UpdatedAt:    toTimestamp(c.UpdatedAt),
+		CheckedOutAt: toOptionalTimestamp(c.CheckedOutAt),
+		ArchivedAt:   toOptionalTimestamp(c.ArchivedAt),
+	}
+}
+
+func toCartItem(item *entities.CartItem) *pb.CartItem {
+	pbItem := &pb.CartItem{
+		Id:        item.UUID,
+		CartId:    item.CartID,
+		ProductId: item.ProductID,
+		Name:      item.ItemName,
+		Price:     int64(item.ItemPrice),
+		Quantity:  int64(item.ItemQuantity),
+		ImageUrl:  item.ItemImageUrl,
+	}
+	// Removed items in events only carry their IDs
+	if !item.CreatedAt.IsZero() {
+		pbItem.CreatedAt = toTimestamp(item.CreatedAt)
+		pbItem.UpdatedAt = toTimestamp(item.UpdatedAt)
+	}
+	return pbItem
+}
+
+func toOrder(o *entities.Order) *pb.Order {
+	return &pb.Order{
+		Id:          o.UUID,
+		CartId:      o.CartID,
+		UserId:      o.UserID,
+		StoreId:     o.StoreID,
+		Total:       int64(o.Total),
+		Currency:    o.Currency,
+		ItemCount:   int64(o.ItemCount),
+		Status:      o.Status,
+		CompletedAt: toTimestamp(o.CompletedAt),
+	}
+}
+
+var eventTypes = map[string]pb.CartEvent_Type{
+	cart.EventItemAdded:   pb.CartEvent_ITEM_ADDED,
+	cart.EventItemUpdated: pb.CartEvent_ITEM_UPDATED,
+	cart.EventItemRemoved: pb.CartEvent_ITEM_REMOVED,
+	cart.EventCartUpdated: pb.CartEvent_CART_UPDATED,
+	cart.EventCartDeleted: pb.CartEvent_CART_DELETED,
+	cart.EventCheckedOut:  pb.CartEvent_CHECKED_OUT,
+}
+
+func toCartEvent(e cart.Event) *pb.CartEvent {
+	event := &pb.CartEvent{
+		Type:   eventTypes[e.Type],
+		CartId: e.CartID,
+		At:     toTimestamp(e.At),
+	}
+	if e.Item != nil {
+		event.Item = toCartItem(e.Item)
+	}
+	return event
+}
+
+func toListOptions(opts *pb.ListOptions) cart.ListOptions {
+	if opts == nil {
+		return cart.ListOptions{}
+	}
+	return cart.ListOptions{
+		Search:   opts.Search,
+		From:     opts.From,
+		To:       opts.To,
+		Archived: opts.Archived,
+		Sort:     opts.Sort,
+		Order:    opts.Order,
+		Cursor:   opts.Cursor,
+		Limit:    int(opts.Limit),
+	}
+}
@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"context"
+	"github.com/rithikjain/quickscan-backend/api/view"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// grpcCodes translates the HTTP status of an error to the closest gRPC
+// code. Anything not listed becomes Internal.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusMethodNotAllowed:      codes.Unimplemented,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+}
+
+// toStatus reports err the way view.Wrap does over HTTP. The message
+// becomes the status message and the stable error code is sent in the
+// "error-code" trailer. Errors that already are gRPC statuses pass through.
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	problem := view.ProblemOf(err)
+	code, ok := grpcCodes[problem.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs("error-code", problem.Code))
+	return status.Error(code, problem.Message)
+}
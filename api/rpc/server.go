@@ -0,0 +1,27 @@
+// Package rpc serves the user and cart services over gRPC for internal
+// consumers such as the POS and warehouse systems. The service definitions
+// are in proto, the code generated from them in pb.
+package rpc
+
+//go:generate protoc -I proto --go_out=plugins=grpc,paths=source_relative:pb proto/user.proto proto/cart.proto
+
+import (
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/rpc/pb"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"google.golang.org/grpc"
+)
+
+// NewServer returns a gRPC server with both services registered behind
+// interceptors checking JWTs with auth. events must be the Events the cart
+// service publishes to, see cart.WithEvents.
+func NewServer(auth *middleware.Auth, userSvc user.Service, cartSvc cart.Service, events *cart.Events) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(unaryAuth(auth)),
+		grpc.StreamInterceptor(streamAuth(auth)),
+	)
+	pb.RegisterUserServiceServer(s, &userServer{users: userSvc})
+	pb.RegisterCartServiceServer(s, &cartServer{carts: cartSvc, events: events})
+	return s
+}
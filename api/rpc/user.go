@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"context"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/rpc/pb"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+type userServer struct {
+	users user.Service
+}
+
+func (s *userServer) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.User, error) {
+	claims, err := middleware.ValidateAndGetClaims(ctx, middleware.AllRoles...)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.users.GetUserByUUID(claims["id"].(string))
+	if err != nil {
+		return nil, err
+	}
+	return toUser(u), nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if _, err := middleware.ValidateAndGetClaims(ctx, entities.RoleStaff, entities.RoleAdmin); err != nil {
+		return nil, err
+	}
+	u, err := s.users.GetUserByUUID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(u), nil
+}
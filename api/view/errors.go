@@ -4,52 +4,135 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/rithikjain/quickscan-backend/pkg"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 )
 
-type ErrView struct {
-	Message string `json:"message"`
-	Status  int    `json:"status"`
+// Problem is an RFC 7807 problem details object. Message repeats Detail for
+// clients of the original error format, which only had message and status.
+type Problem struct {
+	Type    string           `json:"type"`
+	Title   string           `json:"title"`
+	Status  int              `json:"status"`
+	Detail  string           `json:"detail"`
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Errors  []pkg.FieldError `json:"errors,omitempty"`
 }
 
 //noinspection ALL
 var (
-	ErrMethodNotAllowed = errors.New("Error: Method is not allowed")
-	ErrInvalidToken     = errors.New("Error: Invalid Authorization token")
-	ErrUserExists       = errors.New("Error: User already exists")
-	ErrNoParameter      = errors.New("Error: No parameter provided for question ID")
+	ErrMethodNotAllowed = pkg.NewError("method_not_allowed", "Error: Method is not allowed")
+	ErrInvalidToken     = pkg.NewError("invalid_token", "Error: Invalid Authorization token")
+	ErrUserExists       = pkg.NewError("user_exists", "Error: User already exists")
+	ErrNoParameter      = pkg.NewError("no_parameter", "Error: No parameter provided for question ID")
 )
 
-var ErrHTTPStatusMap = map[string]int{
-	pkg.ErrNotFound.Error():     http.StatusNotFound,
-	pkg.ErrInvalidSlug.Error():  http.StatusBadRequest,
-	pkg.ErrExists.Error():       http.StatusConflict,
-	pkg.ErrNoContent.Error():    http.StatusNotFound,
-	pkg.ErrDatabase.Error():     http.StatusInternalServerError,
-	pkg.ErrUnauthorized.Error(): http.StatusUnauthorized,
-	pkg.ErrForbidden.Error():    http.StatusForbidden,
-	pkg.ErrEmail.Error():        http.StatusBadRequest,
-	pkg.ErrPassword.Error():     http.StatusBadRequest,
-	pkg.ErrNotAllowed.Error():   http.StatusBadRequest,
-	ErrMethodNotAllowed.Error(): http.StatusMethodNotAllowed,
-	ErrInvalidToken.Error():     http.StatusBadRequest,
-	ErrUserExists.Error():       http.StatusBadRequest,
-	ErrNoParameter.Error():      http.StatusBadRequest,
+// ErrHTTPStatusMap holds the status of every known error. Errors are matched
+// with errors.Is, so wrapped errors keep their status.
+var ErrHTTPStatusMap = map[error]int{
+	pkg.ErrNotFound:        http.StatusNotFound,
+	pkg.ErrInvalidSlug:     http.StatusBadRequest,
+	pkg.ErrExists:          http.StatusConflict,
+	pkg.ErrNoContent:       http.StatusNotFound,
+	pkg.ErrDatabase:        http.StatusInternalServerError,
+	pkg.ErrUnauthorized:    http.StatusUnauthorized,
+	pkg.ErrForbidden:       http.StatusForbidden,
+	pkg.ErrEmail:           http.StatusBadRequest,
+	pkg.ErrPassword:        http.StatusBadRequest,
+	pkg.ErrNotAllowed:      http.StatusBadRequest,
+	pkg.ErrPhoneNumber:     http.StatusBadRequest,
+	pkg.ErrInvalidCode:     http.StatusUnauthorized,
+	pkg.ErrTooManyRequests: http.StatusTooManyRequests,
+	pkg.ErrTOTPEnabled:     http.StatusConflict,
+	pkg.ErrTOTPNotEnrolled: http.StatusBadRequest,
+	pkg.ErrLocked:          http.StatusTooManyRequests,
+	pkg.ErrName:            http.StatusBadRequest,
+	pkg.ErrImageUrl:        http.StatusBadRequest,
+	pkg.ErrWrongPassword:   http.StatusBadRequest,
+	pkg.ErrTimezone:        http.StatusBadRequest,
+	pkg.ErrCurrency:        http.StatusBadRequest,
+	pkg.ErrCoordinates:     http.StatusBadRequest,
+	pkg.ErrGeofence:        http.StatusBadRequest,
+	pkg.ErrNoLocation:      http.StatusBadRequest,
+	pkg.ErrOutsideStore:    http.StatusForbidden,
+	pkg.ErrOpeningHours:    http.StatusBadRequest,
+	pkg.ErrDate:            http.StatusBadRequest,
+	pkg.ErrStoreClosed:     http.StatusConflict,
+	pkg.ErrCheckedOut:      http.StatusConflict,
+	pkg.ErrEmptyCart:       http.StatusBadRequest,
+	pkg.ErrQuantity:        http.StatusBadRequest,
+	pkg.ErrBudget:          http.StatusBadRequest,
+	pkg.ErrDateRange:       http.StatusBadRequest,
+	pkg.ErrBody:            http.StatusBadRequest,
+	pkg.ErrBodyTooLarge:    http.StatusRequestEntityTooLarge,
+	pkg.ErrValidation:      http.StatusBadRequest,
+	pkg.ErrLimit:           http.StatusBadRequest,
+	pkg.ErrSort:            http.StatusBadRequest,
+	pkg.ErrOrder:           http.StatusBadRequest,
+	pkg.ErrCursor:          http.StatusBadRequest,
+	pkg.ErrArchived:        http.StatusBadRequest,
+	pkg.ErrDisabled:        http.StatusForbidden,
+	pkg.ErrStaleToken:      http.StatusUnauthorized,
+	ErrMethodNotAllowed:    http.StatusMethodNotAllowed,
+	ErrInvalidToken:        http.StatusUnauthorized,
+	ErrUserExists:          http.StatusBadRequest,
+	ErrNoParameter:         http.StatusBadRequest,
 }
 
-func Wrap(err error, w http.ResponseWriter) {
-	msg := err.Error()
-	code := ErrHTTPStatusMap[msg]
+// statusOf finds the status of err, falling back to 500 for errors that
+// are not in ErrHTTPStatusMap.
+func statusOf(err error) int {
+	for known, status := range ErrHTTPStatusMap {
+		if errors.Is(err, known) {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
 
-	if code == 0 {
-		code = http.StatusInternalServerError
+// ProblemOf describes err the way Wrap reports it. Transports other than
+// plain HTTP use it to report errors consistently.
+func ProblemOf(err error) Problem {
+	status := statusOf(err)
+
+	problem := Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  err.Error(),
+		Code:    "internal_error",
+		Message: err.Error(),
+	}
+	var domainErr *pkg.Error
+	if errors.As(err, &domainErr) {
+		problem.Code = domainErr.Code
+	} else {
+		// Unknown errors can carry driver or library details that are of
+		// no use to clients
+		log.Printf("view: unhandled error: %s", err)
+		problem.Detail = "Error: Internal server error"
+		problem.Message = problem.Detail
 	}
+	var validationErr *pkg.ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Errors = validationErr.Fields
+	}
+	return problem
+}
 
-	errView := ErrView{
-		Message: msg,
-		Status:  code,
+func Wrap(err error, w http.ResponseWriter) {
+	problem := ProblemOf(err)
+
+	var retry *pkg.RetryError
+	if errors.As(err, &retry) {
+		seconds := int(math.Ceil(retry.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
 	}
-	w.Header().Add("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(errView)
+
+	w.Header().Add("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg/analytics"
+	"github.com/rithikjain/quickscan-backend/pkg/audit"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"github.com/rithikjain/quickscan-backend/pkg/catalog"
+	"github.com/rithikjain/quickscan-backend/pkg/config"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"github.com/rithikjain/quickscan-backend/pkg/layout"
+	"github.com/rithikjain/quickscan-backend/pkg/notify"
+	"github.com/rithikjain/quickscan-backend/pkg/shoppinglist"
+	"github.com/rithikjain/quickscan-backend/pkg/store"
+	"github.com/rithikjain/quickscan-backend/pkg/user"
+)
+
+// app holds the services of the application. The server and the admin
+// commands share it, so a change made from the command line goes through
+// the same checks as one made over the API.
+type app struct {
+	config    *config.Config
+	audit     audit.Service
+	users     user.Service
+	stores    store.Service
+	inventory inventory.Service
+	catalog   catalog.Service
+	carts     cart.Service
+	// Changes to carts are published for the gRPC WatchCart stream
+	cartEvents *cart.Events
+	layouts    layout.Service
+	lists      shoppinglist.Service
+	analytics  analytics.Service
+}
+
+func newApp(db *gorm.DB, cfg *config.Config) *app {
+	a := &app{config: cfg}
+
+	// Audit log
+	a.audit = audit.NewService(audit.NewRepo(db))
+
+	// Users
+	var attempts user.AttemptStore
+	if cfg.LoginAttemptStore == config.AttemptStoreMemory {
+		attempts = user.NewMemoryAttemptStore()
+	} else {
+		attempts = user.NewPostgresAttemptStore(db)
+	}
+	// The log senders are only allowed locally, config.Load refuses them on
+	// the server
+	sms := user.NewLogSMSSender()
+	if cfg.SMS.Provider == config.SenderTwilio {
+		sms = user.NewTwilioSMSSender(cfg.SMS.AccountSID, cfg.SMS.AuthToken, cfg.SMS.From)
+	}
+	emails := user.NewLogEmailSender()
+	if cfg.Email.Provider == config.SenderSMTP {
+		emails = user.NewSMTPEmailSender(cfg.Email.Host, cfg.Email.Port, cfg.Email.Username, cfg.Email.Password, cfg.Email.From)
+	}
+	a.users = user.NewService(user.NewRepo(db), sms, emails, attempts, a.audit, cfg.SMS.DefaultRegion)
+
+	// Stores
+	a.stores = store.NewService(store.NewRepo(db))
+
+	// Inventory
+	notifier := notify.NewLogNotifier()
+	a.inventory = inventory.NewService(inventory.NewRepo(db), notifier)
+
+	// Catalog
+	a.catalog = catalog.NewService(catalog.NewRepo(db))
+
+	// Cart
+	a.cartEvents = cart.NewEvents()
+	a.carts = cart.WithEvents(cart.NewService(cart.NewRepo(db), a.stores, a.catalog, a.inventory, notifier), a.cartEvents)
+
+	// Store layout
+	a.layouts = layout.NewService(layout.NewRepo(db))
+
+	// Shopping lists
+	a.lists = shoppinglist.NewService(shoppinglist.NewRepo(db))
+
+	// Spending analytics
+	a.analytics = analytics.NewService(analytics.NewRepo(db))
+
+	return a
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strconv"
+	"text/tabwriter"
+)
+
+func runCart(a *app, args []string) error {
+	_, args, err := subcommand("cart", args, "inspect")
+	if err != nil {
+		return err
+	}
+	return inspectCart(a, args)
+}
+
+type cartDetails struct {
+	Cart       *entities.Cart      `json:"cart"`
+	OwnerEmail string              `json:"owner_email"`
+	Items      []entities.CartItem `json:"items"`
+	TotalPrice int                 `json:"total_price"`
+}
+
+// inspectCart shows a cart, whose it is and what is in it, including carts
+// that have been archived or checked out.
+func inspectCart(a *app, args []string) error {
+	fs := flag.NewFlagSet("cart inspect", flag.ContinueOnError)
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: cart inspect <cart id>")
+	}
+
+	c, err := a.carts.GetCart(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	items, err := a.carts.GetCartItems(c.UUID)
+	if err != nil {
+		return err
+	}
+	details := cartDetails{Cart: c, Items: *items}
+	// The owner may have deleted their account since
+	if owner, err := a.users.GetUserByUUID(c.UserID); err == nil {
+		details.OwnerEmail = owner.Email
+	}
+	for _, item := range details.Items {
+		details.TotalPrice += item.ItemPrice * item.ItemQuantity
+	}
+	if out.json {
+		return out.writeJSON(details)
+	}
+
+	w := tabwriter.NewWriter(out.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Cart:\t%s\n", c.UUID)
+	fmt.Fprintf(w, "Name:\t%s\n", c.CartName)
+	fmt.Fprintf(w, "Owner:\t%s %s\n", c.UserID, details.OwnerEmail)
+	fmt.Fprintf(w, "Store:\t%s\n", c.StoreID)
+	fmt.Fprintf(w, "Created at:\t%s\n", formatTime(&c.CreatedAt))
+	fmt.Fprintf(w, "Checked out at:\t%s\n", formatTime(c.CheckedOutAt))
+	fmt.Fprintf(w, "Archived at:\t%s\n", formatTime(c.ArchivedAt))
+	fmt.Fprintf(w, "Budget:\t%d\n", c.Budget)
+	fmt.Fprintf(w, "Total:\t%d\n\n", details.TotalPrice)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(details.Items))
+	for i, item := range details.Items {
+		rows[i] = []string{
+			item.UUID,
+			item.ItemName,
+			item.ProductID,
+			strconv.Itoa(item.ItemPrice),
+			strconv.Itoa(item.ItemQuantity),
+			strconv.Itoa(item.ItemPrice * item.ItemQuantity),
+		}
+	}
+	return out.writeTable([]string{"ITEM", "NAME", "PRODUCT", "PRICE", "QUANTITY", "SUBTOTAL"}, rows)
+}
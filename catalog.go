@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func runCatalog(a *app, args []string) error {
+	_, args, err := subcommand("catalog", args, "import")
+	if err != nil {
+		return err
+	}
+	return importCatalog(a, args)
+}
+
+// importedRow is the outcome of one row of the file. Row counts the header
+// as row 1, the way spreadsheets number them.
+type importedRow struct {
+	Row       int    `json:"row"`
+	Barcode   string `json:"barcode"`
+	ProductID string `json:"product_id,omitempty"`
+	Status    string `json:"status"`
+	Price     *int   `json:"price,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// importCatalog creates the products of a CSV file with a header row of
+// barcode, name, category, image_url and price, in any order. Only barcode
+// and name are required. Products that already exist are left as they are
+// but still get the price. Prices are in the smallest unit of the store's
+// currency and need -store.
+func importCatalog(a *app, args []string) error {
+	fs := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+	storeID := fs.String("store", "", "ID of the store to set the prices at")
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: catalog import [-store id] <file.csv>")
+	}
+	if *storeID != "" {
+		if _, err := a.stores.GetStore(*storeID); err != nil {
+			return fmt.Errorf("store %s: %w", *storeID, err)
+		}
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("reading the header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"barcode", "name"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("the file has no %s column", name)
+		}
+	}
+	if _, ok := columns["price"]; ok && *storeID == "" {
+		return errors.New("the file has a price column, pass -store to set the prices at a store")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []importedRow
+	failed := 0
+	for n := 2; ; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		row := importRow(a, *storeID, &entities.Product{
+			Barcode:  field(record, "barcode"),
+			Name:     field(record, "name"),
+			Category: field(record, "category"),
+			ImageUrl: field(record, "image_url"),
+		}, field(record, "price"))
+		row.Row = n
+		if row.Status == statusFailed {
+			failed++
+		}
+		rows = append(rows, row)
+	}
+
+	table := make([][]string, len(rows))
+	for i, row := range rows {
+		price := "-"
+		if row.Price != nil {
+			price = strconv.Itoa(*row.Price)
+		}
+		table[i] = []string{strconv.Itoa(row.Row), row.Barcode, row.ProductID, row.Status, price, row.Error}
+	}
+	if err := out.print(rows, []string{"ROW", "BARCODE", "PRODUCT", "STATUS", "PRICE", "ERROR"}, table); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
+
+func importRow(a *app, storeID string, product *entities.Product, price string) importedRow {
+	row := importedRow{Barcode: product.Barcode, Status: statusExists}
+	fail := func(err error) importedRow {
+		row.Status = statusFailed
+		row.Error = err.Error()
+		return row
+	}
+
+	existing, err := a.catalog.GetProductByBarcode(product.Barcode)
+	if errors.Is(err, pkg.ErrNotFound) || product.Barcode == "" {
+		existing, err = a.catalog.CreateProduct(product)
+		row.Status = statusCreated
+	}
+	if err != nil {
+		return fail(err)
+	}
+	row.ProductID = existing.UUID
+
+	if price == "" {
+		return row
+	}
+	amount, err := strconv.Atoi(price)
+	if err != nil {
+		return fail(fmt.Errorf("price %q is not a whole number", price))
+	}
+	if _, err := a.catalog.SetPrice(storeID, existing.UUID, amount); err != nil {
+		return fail(err)
+	}
+	row.Price = &amount
+	return row
+}
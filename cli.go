@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: quickscan-backend [command] [flags] [args]
+
+Commands:
+  serve                                   serve the HTTP and gRPC APIs, the default
+  migrate up | down [steps] | status      apply, revert or list schema migrations
+  user create-admin -email E [-name N]    create an admin account, the password is prompted for
+  user disable [-enable] <email | id>     stop an account from logging in, or allow it again
+  user set-role -role R [-store id] <email | id>
+                                          make an account a user, admin or staff of a store
+  cart inspect <cart id>                  show a cart with its items and total
+  catalog import [-store id] <file.csv>   create products from a CSV file and price them at a store
+  seed                                    add a demo store, products and user for local development
+
+The user, cart, catalog and seed commands and migrate status take -o table
+or -o json to choose the output format. Flags go before the arguments.
+
+Settings are read from the environment, then .env, then config.yaml or the
+file named by CONFIG_FILE.
+`
+
+// commands maps the subcommands that run against the services to their
+// implementation. migrate is handled separately since it has to run before
+// the schema is known to be current.
+var commands = map[string]func(a *app, args []string) error{
+	"serve":   runServe,
+	"user":    runUser,
+	"cart":    runCart,
+	"catalog": runCatalog,
+	"seed":    runSeed,
+}
+
+// Outcomes of creating something from the command line.
+const (
+	statusCreated = "created"
+	statusExists  = "exists"
+	statusFailed  = "failed"
+)
+
+// output prints the result of a command, as aligned columns for people or
+// as JSON for scripts.
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+// parseFlags parses args with the -o flag added to fs.
+func parseFlags(fs *flag.FlagSet, args []string) (*output, error) {
+	format := fs.String("o", "table", "output format, table or json")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	switch *format {
+	case "table":
+		return &output{w: os.Stdout}, nil
+	case "json":
+		return &output{json: true, w: os.Stdout}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, use table or json", *format)
+}
+
+func (o *output) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (o *output) writeTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// print writes v as JSON or the rows as a table.
+func (o *output) print(v interface{}, header []string, rows [][]string) error {
+	if o.json {
+		return o.writeJSON(v)
+	}
+	return o.writeTable(header, rows)
+}
+
+// subcommand splits args into the name of a subcommand and its arguments.
+func subcommand(group string, args []string, names ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, name := range names {
+			if args[0] == name {
+				return name, args[1:], nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("usage: %s %s", group, strings.Join(names, " | "))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05 MST")
+}
@@ -5,9 +5,18 @@ go 1.14
 require (
 	github.com/auth0/go-jwt-middleware v0.0.0-20200507191422-d30d7b9ece63
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/golang/protobuf v1.4.2
+	github.com/gorilla/mux v1.7.4
+	github.com/graph-gophers/graphql-go v1.1.0
 	github.com/jinzhu/gorm v1.9.15
 	github.com/joho/godotenv v1.3.0
-	github.com/lib/pq v1.7.1 // indirect
+	github.com/lib/pq v1.7.1
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d
+	github.com/nyaruka/phonenumbers v1.0.75
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	github.com/swaggo/files v0.0.0-20210815190702-a29dd2bc99b2
 	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
+	google.golang.org/grpc v1.30.0
+	google.golang.org/protobuf v1.25.0
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -4,88 +4,62 @@ import (
 	"fmt"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
-	"github.com/joho/godotenv"
-	"github.com/rithikjain/quickscan-backend/api/handler"
-	"github.com/rithikjain/quickscan-backend/pkg/cart"
-	"github.com/rithikjain/quickscan-backend/pkg/entities"
-	"github.com/rithikjain/quickscan-backend/pkg/user"
+	"github.com/rithikjain/quickscan-backend/pkg/config"
+	"github.com/rithikjain/quickscan-backend/pkg/migrate"
 	"log"
-	"net/http"
 	"os"
 )
 
-func dbConnect(host, port, user, dbname, password, sslmode string) (*gorm.DB, error) {
-	// In the case of heroku
-	if os.Getenv("DATABASE_URL") != "" {
-		return gorm.Open("postgres", os.Getenv("DATABASE_URL"))
+func main() {
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
 	}
-	db, err := gorm.Open(
-		"postgres",
-		fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s", host, port, user, dbname, password, sslmode),
-	)
-
-	return db, err
-}
-
-func GetPort() string {
-	var port = os.Getenv("PORT")
-	if port == "" {
-		fmt.Println("INFO: No PORT environment variable detected, defaulting to 4000")
-		return "localhost:4000"
+	run, ok := commands[cmd]
+	if cmd != "migrate" && !ok {
+		fmt.Fprint(os.Stderr, usage)
+		if cmd == "help" || cmd == "-h" || cmd == "--help" {
+			return
+		}
+		os.Exit(2)
 	}
-	return ":" + port
-}
 
-func main() {
-	if os.Getenv("onServer") != "True" {
-		// Loading the .env file
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatal("Error loading .env file")
-		}
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
+	log.Printf("Loaded config:\n%s", cfg)
 
 	// Setting up DB
-	db, err := dbConnect(
-		os.Getenv("dbHost"),
-		os.Getenv("dbPort"),
-		os.Getenv("dbUser"),
-		os.Getenv("dbName"),
-		os.Getenv("dbPass"),
-		os.Getenv("sslmode"),
-	)
+	db, err := gorm.Open("postgres", cfg.Database.DSN())
 	if err != nil {
 		log.Fatalf("Error connecting to the database: %s", err.Error())
 	}
 
-	// Creating the tables
-	db.AutoMigrate(&entities.User{})
-	db.AutoMigrate(&entities.Cart{})
-	db.AutoMigrate(&entities.CartItem{})
-
 	defer db.Close()
-	fmt.Println("Connected to DB...")
-
-	// Setting up the router
-	r := http.NewServeMux()
+	fmt.Fprintln(os.Stderr, "Connected to DB...")
 
-	// Users
-	userRepo := user.NewRepo(db)
-	userSvc := user.NewService(userRepo)
-	handler.MakeUserHandler(r, userSvc)
-
-	// Cart
-	cartRepo := cart.NewRepo(db)
-	cartSvc := cart.NewService(cartRepo)
-	handler.MakeCartHandler(r, cartSvc)
-
-	// To check if server up or not
-	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("Hello There"))
+	migrator, err := migrate.New(db.DB(), migrate.All)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cmd == "migrate" {
+		if err := runMigrate(migrator, args); err != nil {
+			log.Fatal(err)
+		}
 		return
-	})
+	}
 
-	fmt.Println("Serving...")
-	log.Fatal(http.ListenAndServe(GetPort(), r))
+	// The schema only changes through "migrate up", never on boot
+	pending, err := migrator.Pending()
+	if err != nil {
+		log.Fatalf("Error checking migrations: %s", err.Error())
+	}
+	if len(pending) > 0 {
+		log.Fatalf("%d migrations pending, run \"%s migrate up\" first", len(pending), os.Args[0])
+	}
+
+	if err := run(newApp(db, cfg), args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg/migrate"
+	"strconv"
+	"time"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status [-o table | json]"
+
+// runMigrate handles the migrate subcommand. down reverts one migration
+// unless told how many.
+func runMigrate(m *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up()
+		for _, mig := range done {
+			fmt.Printf("Applied %d %s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Nothing to apply, the schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return errors.New(migrateUsage)
+			}
+			steps = n
+		}
+		done, err := m.Down(steps)
+		for _, mig := range done {
+			fmt.Printf("Reverted %d %s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		return migrateStatus(m, args[1:])
+	}
+	return errors.New(migrateUsage)
+}
+
+// migrationStatus is a line of migrate status, AppliedAt is null while the
+// migration is pending.
+type migrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func migrateStatus(m *migrate.Migrator, args []string) error {
+	fs := flag.NewFlagSet("migrate status", flag.ContinueOnError)
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(migrateUsage)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	lines := make([]migrationStatus, len(statuses))
+	rows := make([][]string, len(statuses))
+	for i, s := range statuses {
+		lines[i] = migrationStatus{Version: s.Version, Name: s.Name, AppliedAt: s.AppliedAt}
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = formatTime(s.AppliedAt)
+		}
+		rows[i] = []string{strconv.Itoa(s.Version), s.Name, applied}
+	}
+	return out.print(lines, []string{"VERSION", "NAME", "APPLIED AT"}, rows)
+}
@@ -0,0 +1,184 @@
+package analytics
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strings"
+	"time"
+)
+
+// Range limits aggregations to orders completed in [From, To). Zero times
+// leave that side open.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+type MonthSpend struct {
+	Month  string `json:"month"`
+	Orders int    `json:"orders"`
+	Total  int    `json:"total"`
+}
+
+type StoreSpend struct {
+	StoreID   string `json:"store_id"`
+	StoreName string `json:"store_name"`
+	Currency  string `json:"currency"`
+	Orders    int    `json:"orders"`
+	Total     int    `json:"total"`
+}
+
+type CategorySpend struct {
+	Category string `json:"category"`
+	Quantity int    `json:"quantity"`
+	Total    int    `json:"total"`
+}
+
+type ProductSpend struct {
+	ProductID string `json:"product_id"`
+	ItemName  string `json:"item_name"`
+	Orders    int    `json:"orders"`
+	Quantity  int    `json:"quantity"`
+	Total     int    `json:"total"`
+}
+
+type BasketStats struct {
+	Orders       int     `json:"orders"`
+	Total        int     `json:"total"`
+	AverageTotal float64 `json:"average_total"`
+	AverageItems float64 `json:"average_items"`
+}
+
+// Repository aggregates in SQL so that no order or item rows are loaded
+// into memory.
+type Repository interface {
+	SpendByMonth(userID string, rng Range) ([]MonthSpend, error)
+
+	SpendByStore(userID string, rng Range) ([]StoreSpend, error)
+
+	SpendByCategory(userID string, rng Range) ([]CategorySpend, error)
+
+	TopProducts(userID string, rng Range, limit int) ([]ProductSpend, error)
+
+	Basket(userID string, rng Range) (*BasketStats, error)
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+// orderFilter builds the WHERE clause shared by every aggregation over the
+// orders table aliased as o.
+func orderFilter(userID string, rng Range) (string, []interface{}) {
+	conds := []string{"o.deleted_at IS NULL", "o.user_id = ?", "o.status = ?"}
+	args := []interface{}{userID, entities.OrderStatusCompleted}
+	if !rng.From.IsZero() {
+		conds = append(conds, "o.completed_at >= ?")
+		args = append(args, rng.From)
+	}
+	if !rng.To.IsZero() {
+		conds = append(conds, "o.completed_at < ?")
+		args = append(args, rng.To)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *repo) SpendByMonth(userID string, rng Range) ([]MonthSpend, error) {
+	where, args := orderFilter(userID, rng)
+	months := []MonthSpend{}
+	err := r.DB.Raw(`
+		SELECT to_char(date_trunc('month', o.completed_at), 'YYYY-MM') AS month,
+			COUNT(*) AS orders,
+			SUM(o.total) AS total
+		FROM orders o
+		`+where+`
+		GROUP BY 1
+		ORDER BY 1`, args...).Scan(&months).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return months, nil
+}
+
+func (r *repo) SpendByStore(userID string, rng Range) ([]StoreSpend, error) {
+	where, args := orderFilter(userID, rng)
+	stores := []StoreSpend{}
+	err := r.DB.Raw(`
+		SELECT o.store_id,
+			COALESCE(s.name, '') AS store_name,
+			o.currency,
+			COUNT(*) AS orders,
+			SUM(o.total) AS total
+		FROM orders o
+		LEFT JOIN stores s ON s.uuid = o.store_id AND s.deleted_at IS NULL
+		`+where+`
+		GROUP BY o.store_id, s.name, o.currency
+		ORDER BY total DESC`, args...).Scan(&stores).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return stores, nil
+}
+
+func (r *repo) SpendByCategory(userID string, rng Range) ([]CategorySpend, error) {
+	where, args := orderFilter(userID, rng)
+	categories := []CategorySpend{}
+	err := r.DB.Raw(`
+		SELECT COALESCE(NULLIF(p.category, ''), 'uncategorised') AS category,
+			SUM(i.item_quantity) AS quantity,
+			SUM(i.item_price * i.item_quantity) AS total
+		FROM orders o
+		JOIN order_lines i ON i.order_id = o.uuid
+		LEFT JOIN products p ON p.uuid = i.product_id AND p.deleted_at IS NULL
+		`+where+`
+		GROUP BY 1
+		ORDER BY total DESC`, args...).Scan(&categories).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return categories, nil
+}
+
+func (r *repo) TopProducts(userID string, rng Range, limit int) ([]ProductSpend, error) {
+	where, args := orderFilter(userID, rng)
+	products := []ProductSpend{}
+	err := r.DB.Raw(`
+		SELECT i.product_id,
+			i.item_name,
+			COUNT(DISTINCT o.uuid) AS orders,
+			SUM(i.item_quantity) AS quantity,
+			SUM(i.item_price * i.item_quantity) AS total
+		FROM orders o
+		JOIN order_lines i ON i.order_id = o.uuid
+		`+where+`
+		GROUP BY i.product_id, i.item_name
+		ORDER BY quantity DESC, total DESC
+		LIMIT ?`, append(args, limit)...).Scan(&products).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return products, nil
+}
+
+func (r *repo) Basket(userID string, rng Range) (*BasketStats, error) {
+	where, args := orderFilter(userID, rng)
+	stats := &BasketStats{}
+	err := r.DB.Raw(`
+		SELECT COUNT(*) AS orders,
+			COALESCE(SUM(o.total), 0) AS total,
+			COALESCE(AVG(o.total), 0) AS average_total,
+			COALESCE(AVG(o.item_count), 0) AS average_items
+		FROM orders o
+		`+where, args...).Row().Scan(&stats.Orders, &stats.Total, &stats.AverageTotal, &stats.AverageItems)
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return stats, nil
+}
@@ -0,0 +1,108 @@
+package analytics
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	defaultTopProducts = 10
+	maxTopProducts     = 100
+)
+
+// Dates passed to the service are YYYY-MM-DD and inclusive on both ends.
+// Empty dates leave that end of the range open.
+type Service interface {
+	SpendByMonth(userID, fromDate, toDate string) ([]MonthSpend, error)
+
+	SpendByStore(userID, fromDate, toDate string) ([]StoreSpend, error)
+
+	SpendByCategory(userID, fromDate, toDate string) ([]CategorySpend, error)
+
+	// TopProducts returns the most bought products by quantity. A limit of
+	// 0 falls back to the default of 10.
+	TopProducts(userID, fromDate, toDate string, limit int) ([]ProductSpend, error)
+
+	Basket(userID, fromDate, toDate string) (*BasketStats, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+func (s *service) SpendByMonth(userID, fromDate, toDate string) ([]MonthSpend, error) {
+	rng, err := parseRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SpendByMonth(userID, rng)
+}
+
+func (s *service) SpendByStore(userID, fromDate, toDate string) ([]StoreSpend, error) {
+	rng, err := parseRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SpendByStore(userID, rng)
+}
+
+func (s *service) SpendByCategory(userID, fromDate, toDate string) ([]CategorySpend, error) {
+	rng, err := parseRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SpendByCategory(userID, rng)
+}
+
+func (s *service) TopProducts(userID, fromDate, toDate string, limit int) ([]ProductSpend, error) {
+	rng, err := parseRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = defaultTopProducts
+	}
+	if limit < 0 || limit > maxTopProducts {
+		return nil, pkg.ErrLimit
+	}
+	return s.repo.TopProducts(userID, rng, limit)
+}
+
+func (s *service) Basket(userID, fromDate, toDate string) (*BasketStats, error) {
+	rng, err := parseRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Basket(userID, rng)
+}
+
+// parseRange turns inclusive dates into a half open Range.
+func parseRange(fromDate, toDate string) (Range, error) {
+	var rng Range
+	if fromDate != "" {
+		from, err := time.Parse(dateLayout, fromDate)
+		if err != nil {
+			return rng, pkg.ErrDate
+		}
+		rng.From = from
+	}
+	if toDate != "" {
+		to, err := time.Parse(dateLayout, toDate)
+		if err != nil {
+			return rng, pkg.ErrDate
+		}
+		rng.To = to.AddDate(0, 0, 1)
+	}
+	if !rng.From.IsZero() && !rng.To.IsZero() && !rng.From.Before(rng.To) {
+		return rng, pkg.ErrDateRange
+	}
+	return rng, nil
+}
@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type Repository interface {
+	Create(entry *entities.AuditEntry) (*entities.AuditEntry, error)
+
+	GetByUser(userID string) (*[]entities.AuditEntry, error)
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) Create(entry *entities.AuditEntry) (*entities.AuditEntry, error) {
+	result := r.DB.Create(entry)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return entry, nil
+}
+
+func (r *repo) GetByUser(userID string) (*[]entities.AuditEntry, error) {
+	var entries []entities.AuditEntry
+	err := r.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&entries).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &entries, nil
+}
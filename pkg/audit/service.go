@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"log"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionLoginLockout   = "login.lockout"
+	ActionAccountDelete  = "account.delete"
+	ActionAccountDisable = "account.disable"
+	ActionAccountEnable  = "account.enable"
+)
+
+type Service interface {
+	Record(action, userID, ip, detail string)
+
+	GetUserEntries(userID string) (*[]entities.AuditEntry, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+// Record stores an audit entry. Failures are only logged so that auditing
+// never breaks the operation being audited.
+func (s *service) Record(action, userID, ip, detail string) {
+	_, err := s.repo.Create(&entities.AuditEntry{
+		Action: action,
+		UserID: userID,
+		IP:     ip,
+		Detail: detail,
+	})
+	if err != nil {
+		log.Printf("audit: could not record %s for %q: %s", action, userID, err)
+	}
+}
+
+func (s *service) GetUserEntries(userID string) (*[]entities.AuditEntry, error) {
+	return s.repo.GetByUser(userID)
+}
@@ -0,0 +1,219 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"log"
+	"time"
+)
+
+const (
+	BudgetScopeCart    = "cart"
+	BudgetScopeMonthly = "monthly"
+)
+
+// budgetThresholds are the percentages of a budget at which the user is
+// warned, in ascending order.
+var budgetThresholds = []int{80, 100}
+
+// BudgetStatus is how much of one budget has been used. Amounts are in the
+// same minor units as item prices.
+type BudgetStatus struct {
+	Scope       string `json:"scope"`
+	Budget      int    `json:"budget"`
+	Spent       int    `json:"spent"`
+	Remaining   int    `json:"remaining"`
+	PercentUsed int    `json:"percent_used"`
+	// Month is the calendar month of the monthly budget, as 2006-01
+	Month string `json:"month,omitempty"`
+}
+
+// BudgetWarning is raised when a change pushes spending past a threshold.
+type BudgetWarning struct {
+	Scope     string `json:"scope"`
+	Threshold int    `json:"threshold"`
+	Message   string `json:"message"`
+}
+
+// BudgetReport holds the budgets that apply to a cart. Budgets that are not
+// set are left nil.
+type BudgetReport struct {
+	Cart     *BudgetStatus   `json:"cart,omitempty"`
+	Monthly  *BudgetStatus   `json:"monthly,omitempty"`
+	Warnings []BudgetWarning `json:"warnings"`
+}
+
+func (s *service) SetCartBudget(cartID, userID string, amount int) (*entities.Cart, error) {
+	if amount < 0 {
+		return nil, pkg.ErrBudget
+	}
+	cart, err := s.ownCart(cartID, userID)
+	if err != nil {
+		return nil, err
+	}
+	cart.Budget = amount
+	return s.repo.SaveCart(cart)
+}
+
+func (s *service) SetMonthlyBudget(userID string, amount int, timezone string) (*entities.MonthlyBudget, error) {
+	if amount < 0 {
+		return nil, pkg.ErrBudget
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, pkg.ErrTimezone
+		}
+	}
+	budget, err := s.repo.FindMonthlyBudget(userID)
+	if errors.Is(err, pkg.ErrNotFound) {
+		budget = &entities.MonthlyBudget{UserID: userID, Timezone: "UTC"}
+	} else if err != nil {
+		return nil, err
+	}
+	budget.Amount = amount
+	if timezone != "" {
+		budget.Timezone = timezone
+	}
+	return s.repo.SaveMonthlyBudget(budget)
+}
+
+func (s *service) GetBudgetReport(cartID, userID string) (*BudgetReport, error) {
+	if cartID == "" {
+		report := &BudgetReport{Warnings: []BudgetWarning{}}
+		monthly, err := s.monthlyStatus(userID, 0)
+		if err != nil {
+			return nil, err
+		}
+		report.Monthly = monthly
+		return report, nil
+	}
+	cart, err := s.ownCart(cartID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.budgetReport(cart, 0)
+}
+
+// budgetReport computes the budgets of the cart after a change of delta to
+// its total, warning the user about every threshold the change crossed.
+func (s *service) budgetReport(cart *entities.Cart, delta int) (*BudgetReport, error) {
+	report := &BudgetReport{Warnings: []BudgetWarning{}}
+
+	cartTotal, err := s.repo.CartTotal(cart.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Budget > 0 {
+		report.Cart = newBudgetStatus(BudgetScopeCart, cart.Budget, cartTotal)
+		report.Warnings = append(report.Warnings, crossed(BudgetScopeCart, cart.Budget, cartTotal-delta, cartTotal)...)
+	}
+
+	// An open cart counts towards the month it is being shopped in
+	spentBefore := 0
+	if cart.CheckedOutAt == nil {
+		spentBefore = cartTotal
+	}
+	monthly, err := s.monthlyStatus(cart.UserID, spentBefore)
+	if err != nil {
+		return nil, err
+	}
+	if monthly != nil {
+		report.Monthly = monthly
+		report.Warnings = append(report.Warnings, crossed(BudgetScopeMonthly, monthly.Budget, monthly.Spent-delta, monthly.Spent)...)
+	}
+
+	for _, warning := range report.Warnings {
+		period := cart.UUID
+		if warning.Scope == BudgetScopeMonthly {
+			period = report.Monthly.Month
+		}
+		s.notifyBudget(cart.UserID, period, warning)
+	}
+	return report, nil
+}
+
+// notifyBudget sends the warning unless the user was already notified
+// about its threshold in the period, when a cart goes back and forth over
+// it for example.
+func (s *service) notifyBudget(userID, period string, warning BudgetWarning) {
+	fresh, err := s.repo.RecordBudgetAlert(&entities.BudgetAlert{
+		UserID:    userID,
+		Scope:     warning.Scope,
+		Period:    period,
+		Threshold: warning.Threshold,
+	})
+	if err != nil {
+		log.Printf("cart: recording budget alert for %s failed: %s", userID, err)
+		return
+	}
+	if !fresh {
+		return
+	}
+	if err := s.notifier.Notify(userID, "Budget alert", warning.Message); err != nil {
+		log.Printf("cart: budget alert for %s failed: %s", userID, err)
+	}
+}
+
+// monthlyStatus reports the user's monthly budget with extra added to the
+// orders completed this month, or nil when no budget is set.
+func (s *service) monthlyStatus(userID string, extra int) (*BudgetStatus, error) {
+	budget, err := s.repo.FindMonthlyBudget(userID)
+	if errors.Is(err, pkg.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if budget.Amount == 0 {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(budget.Timezone)
+	if err != nil {
+		return nil, pkg.ErrTimezone
+	}
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	spent, err := s.repo.OrdersTotal(userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	status := newBudgetStatus(BudgetScopeMonthly, budget.Amount, spent+extra)
+	status.Month = from.Format("2006-01")
+	return status, nil
+}
+
+func newBudgetStatus(scope string, budget, spent int) *BudgetStatus {
+	return &BudgetStatus{
+		Scope:       scope,
+		Budget:      budget,
+		Spent:       spent,
+		Remaining:   budget - spent,
+		PercentUsed: spent * 100 / budget,
+	}
+}
+
+// crossed returns a warning for every threshold that spending went past
+// when moving from before to after.
+func crossed(scope string, budget, before, after int) []BudgetWarning {
+	var warnings []BudgetWarning
+	for _, threshold := range budgetThresholds {
+		limit := budget * threshold / 100
+		if before < limit && after >= limit {
+			var message string
+			if threshold >= 100 {
+				message = fmt.Sprintf("You have reached your %s budget", scope)
+			} else {
+				message = fmt.Sprintf("You have used %d%% of your %s budget", threshold, scope)
+			}
+			warnings = append(warnings, BudgetWarning{
+				Scope:     scope,
+				Threshold: threshold,
+				Message:   message,
+			})
+		}
+	}
+	return warnings
+}
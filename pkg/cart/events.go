@@ -0,0 +1,199 @@
+package cart
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"log"
+	"sync"
+	"time"
+)
+
+// Types of cart events.
+const (
+	EventItemAdded   = "item_added"
+	EventItemUpdated = "item_updated"
+	EventItemRemoved = "item_removed"
+	EventCartUpdated = "cart_updated"
+	EventCartDeleted = "cart_deleted"
+	EventCheckedOut  = "checked_out"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// further events to it are dropped.
+const eventBuffer = 16
+
+// Event is a change made to a cart. Item is set for item events, removed
+// items only carry their UUID and cart.
+type Event struct {
+	Type   string
+	CartID string
+	Item   *entities.CartItem
+	At     time.Time
+}
+
+// Events fans the events of each cart out to its subscribers. A slow
+// subscriber misses events rather than holding up the change that caused
+// them.
+//
+// Events are kept in memory. Subscribers only hear of the changes made
+// through WithEvents in the same process, not of those made by other
+// instances of the application or by the service itself, such as the
+// trash purge. Clients should fetch the cart when they subscribe and treat
+// the events as hints to refresh, not as a complete log.
+type Events struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Event]bool
+}
+
+func NewEvents() *Events {
+	return &Events{
+		subs: map[string]map[chan Event]bool{},
+	}
+}
+
+// Subscribe returns the events of the cart from now on. Calling cancel
+// stops them and closes the channel.
+func (e *Events) Subscribe(cartID string) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, eventBuffer)
+	e.mu.Lock()
+	if e.subs[cartID] == nil {
+		e.subs[cartID] = map[chan Event]bool{}
+	}
+	e.subs[cartID][ch] = true
+	e.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.mu.Lock()
+			delete(e.subs[cartID], ch)
+			if len(e.subs[cartID]) == 0 {
+				delete(e.subs, cartID)
+			}
+			e.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (e *Events) Publish(event Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subs[event.CartID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("cart: dropped %s event of cart %s for a slow subscriber", event.Type, event.CartID)
+		}
+	}
+}
+
+// WithEvents wraps svc so that every change made to a cart through it is
+// published to events.
+func WithEvents(svc Service, events *Events) Service {
+	return &eventService{
+		Service: svc,
+		events:  events,
+	}
+}
+
+type eventService struct {
+	Service
+	events *Events
+}
+
+func (s *eventService) publish(eventType, cartID string, item *entities.CartItem) {
+	s.events.Publish(Event{Type: eventType, CartID: cartID, Item: item, At: time.Now()})
+}
+
+func (s *eventService) ChangeCartName(cartID string, name string) (*entities.Cart, error) {
+	c, err := s.Service.ChangeCartName(cartID, name)
+	if err == nil {
+		s.publish(EventCartUpdated, c.UUID, nil)
+	}
+	return c, err
+}
+
+func (s *eventService) UpdateCart(cartID, userID string, update *CartUpdate) (*entities.Cart, error) {
+	c, err := s.Service.UpdateCart(cartID, userID, update)
+	if err == nil {
+		s.publish(EventCartUpdated, cartID, nil)
+	}
+	return c, err
+}
+
+func (s *eventService) CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, *BudgetReport, error) {
+	item, report, err := s.Service.CreateCartItem(cartItem)
+	if err == nil {
+		s.publish(EventItemAdded, item.CartID, item)
+	}
+	return item, report, err
+}
+
+func (s *eventService) UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, *BudgetReport, error) {
+	item, report, err := s.Service.UpdateCartItemCount(cartItemID, newCount)
+	if err == nil {
+		s.publish(EventItemUpdated, item.CartID, item)
+	}
+	return item, report, err
+}
+
+func (s *eventService) DeleteCartItem(cartItemID string) error {
+	// The item is looked up first, it cannot be found once deleted
+	item, err := s.Service.GetCartItem(cartItemID)
+	if err != nil {
+		return err
+	}
+	if err := s.Service.DeleteCartItem(cartItemID); err != nil {
+		return err
+	}
+	s.publish(EventItemRemoved, item.CartID, &entities.CartItem{UUID: item.UUID, CartID: item.CartID})
+	return nil
+}
+
+func (s *eventService) Checkout(cartID, userID string) (*entities.Order, error) {
+	order, err := s.Service.Checkout(cartID, userID)
+	if err == nil {
+		s.publish(EventCheckedOut, cartID, nil)
+	}
+	return order, err
+}
+
+func (s *eventService) ArchiveCart(cartID, userID string, archived bool) (*entities.Cart, error) {
+	c, err := s.Service.ArchiveCart(cartID, userID, archived)
+	if err == nil {
+		s.publish(EventCartUpdated, cartID, nil)
+	}
+	return c, err
+}
+
+func (s *eventService) DeleteCart(cartID, userID string) error {
+	err := s.Service.DeleteCart(cartID, userID)
+	if err == nil {
+		s.publish(EventCartDeleted, cartID, nil)
+	}
+	return err
+}
+
+func (s *eventService) PurgeCart(cartID, userID string) error {
+	err := s.Service.PurgeCart(cartID, userID)
+	if err == nil {
+		s.publish(EventCartDeleted, cartID, nil)
+	}
+	return err
+}
+
+func (s *eventService) RestoreCart(cartID, userID string) (*entities.Cart, error) {
+	c, err := s.Service.RestoreCart(cartID, userID)
+	if err == nil {
+		s.publish(EventCartUpdated, cartID, nil)
+	}
+	return c, err
+}
+
+func (s *eventService) SetCartBudget(cartID, userID string, amount int) (*entities.Cart, error) {
+	c, err := s.Service.SetCartBudget(cartID, userID, amount)
+	if err == nil {
+		s.publish(EventCartUpdated, cartID, nil)
+	}
+	return c, err
+}
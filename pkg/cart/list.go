@@ -0,0 +1,258 @@
+package cart
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strconv"
+	"time"
+)
+
+// Sort keys of ListCarts and ListCartItems. Carts sorted by price are
+// ordered by their total.
+const (
+	SortCreated = "created"
+	SortUpdated = "updated"
+	SortName    = "name"
+	SortPrice   = "price"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+
+	dateLayout = "2006-01-02"
+)
+
+// ListOptions page, sort and filter a listing as the client asks for it.
+// From and To are YYYY-MM-DD creation dates, inclusive on both ends.
+// Archived is "true", "false" or empty for both and only applies to carts.
+// Cursor is the NextCursor of the previous page. Empty values fall back to
+// oldest first, 50 per page.
+type ListOptions struct {
+	Search   string
+	From     string
+	To       string
+	Archived string
+	Sort     string
+	Order    string
+	Cursor   string
+	Limit    int
+}
+
+// Query is a checked listing for the repository. From and To bound the
+// creation time as a half open range, zero ends are open.
+type Query struct {
+	Search   string
+	From     time.Time
+	To       time.Time
+	Archived *bool
+	Sort     string
+	Desc     bool
+	After    *Position
+	Limit    int
+}
+
+// Position is the sort value and ID of the last row of a page, the next
+// page starts right after it.
+type Position struct {
+	Value interface{}
+	ID    uint
+}
+
+// cursor is the client side form of a Position. It remembers the sort it
+// was made for so it cannot be replayed against another ordering.
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+type CartPage struct {
+	Carts []entities.Cart `json:"carts"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor"`
+}
+
+type ItemPage struct {
+	Items      []entities.CartItem `json:"items"`
+	NextCursor string              `json:"next_cursor"`
+	// Total is the price of the whole cart, not only of this page.
+	Total int `json:"total_price"`
+}
+
+func (s *service) ListCarts(userID string, opts ListOptions) (*CartPage, error) {
+	q, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Archived != "" {
+		archived, err := strconv.ParseBool(opts.Archived)
+		if err != nil {
+			return nil, pkg.ErrArchived
+		}
+		q.Archived = &archived
+	}
+
+	limit := q.Limit
+	q.Limit++
+	carts, err := s.repo.ListCarts(userID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CartPage{Carts: carts}
+	if len(carts) > limit {
+		page.Carts = carts[:limit]
+		last := page.Carts[limit-1]
+		var value string
+		switch q.Sort {
+		case SortCreated:
+			value = last.CreatedAt.Format(time.RFC3339Nano)
+		case SortUpdated:
+			value = last.UpdatedAt.Format(time.RFC3339Nano)
+		case SortName:
+			value = last.CartName
+		case SortPrice:
+			total, err := s.repo.CartTotal(last.UUID)
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.Itoa(total)
+		}
+		page.NextCursor = encodeCursor(cursor{Sort: q.Sort, Desc: q.Desc, Value: value, ID: last.ID})
+	}
+	return page, nil
+}
+
+func (s *service) ListCartItems(cartID string, opts ListOptions) (*ItemPage, error) {
+	q, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	q.Limit++
+	items, err := s.repo.ListCartItems(cartID, q)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.CartTotal(cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		var value string
+		switch q.Sort {
+		case SortCreated:
+			value = last.CreatedAt.Format(time.RFC3339Nano)
+		case SortUpdated:
+			value = last.UpdatedAt.Format(time.RFC3339Nano)
+		case SortName:
+			value = last.ItemName
+		case SortPrice:
+			value = strconv.Itoa(last.ItemPrice)
+		}
+		page.NextCursor = encodeCursor(cursor{Sort: q.Sort, Desc: q.Desc, Value: value, ID: last.ID})
+	}
+	return page, nil
+}
+
+// parseListOptions checks the options shared by every listing.
+func parseListOptions(opts ListOptions) (Query, error) {
+	q := Query{Search: opts.Search, Sort: opts.Sort, Limit: opts.Limit}
+
+	switch q.Sort {
+	case "":
+		q.Sort = SortCreated
+	case SortCreated, SortUpdated, SortName, SortPrice:
+	default:
+		return q, pkg.ErrSort
+	}
+	switch opts.Order {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, pkg.ErrOrder
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultPageSize
+	}
+	if q.Limit < 0 || q.Limit > maxPageSize {
+		return q, pkg.ErrLimit
+	}
+
+	if opts.From != "" {
+		from, err := time.Parse(dateLayout, opts.From)
+		if err != nil {
+			return q, pkg.ErrDate
+		}
+		q.From = from
+	}
+	if opts.To != "" {
+		to, err := time.Parse(dateLayout, opts.To)
+		if err != nil {
+			return q, pkg.ErrDate
+		}
+		q.To = to.AddDate(0, 0, 1)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return q, pkg.ErrDateRange
+	}
+
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor, q)
+		if err != nil {
+			return q, err
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor turns a cursor back into a Position, rejecting cursors
+// that were made for a different sort.
+func decodeCursor(s string, q Query) (*Position, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, pkg.ErrCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, pkg.ErrCursor
+	}
+	if c.Sort != q.Sort || c.Desc != q.Desc {
+		return nil, pkg.ErrCursor
+	}
+
+	pos := &Position{ID: c.ID}
+	switch c.Sort {
+	case SortCreated, SortUpdated:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, pkg.ErrCursor
+		}
+		pos.Value = t
+	case SortPrice:
+		n, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, pkg.ErrCursor
+		}
+		pos.Value = n
+	default:
+		pos.Value = c.Value
+	}
+	return pos, nil
+}
@@ -0,0 +1,251 @@
+package cart
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func TestParseListOptions(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(dateLayout, s)
+		return d
+	}
+	tests := []struct {
+		name string
+		opts ListOptions
+		want Query
+		err  error
+	}{
+		{"defaults", ListOptions{}, Query{Sort: SortCreated, Limit: defaultPageSize}, nil},
+		{"sort and order", ListOptions{Sort: SortPrice, Order: "desc", Limit: 10}, Query{Sort: SortPrice, Desc: true, Limit: 10}, nil},
+		{"ascending", ListOptions{Sort: SortName, Order: "asc"}, Query{Sort: SortName, Limit: defaultPageSize}, nil},
+		{"dates are inclusive", ListOptions{From: "2026-10-01", To: "2026-10-01"}, Query{Sort: SortCreated, Limit: defaultPageSize, From: day("2026-10-01"), To: day("2026-10-02")}, nil},
+		{"unknown sort", ListOptions{Sort: "id"}, Query{}, pkg.ErrSort},
+		{"unknown order", ListOptions{Order: "up"}, Query{}, pkg.ErrOrder},
+		{"limit too large", ListOptions{Limit: maxPageSize + 1}, Query{}, pkg.ErrLimit},
+		{"negative limit", ListOptions{Limit: -1}, Query{}, pkg.ErrLimit},
+		{"bad date", ListOptions{From: "01/10/2026"}, Query{}, pkg.ErrDate},
+		{"reversed range", ListOptions{From: "2026-10-02", To: "2026-10-01"}, Query{}, pkg.ErrDateRange},
+		{"bad cursor", ListOptions{Cursor: "not a cursor"}, Query{}, pkg.ErrCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseListOptions(tt.opts)
+			if err != tt.err {
+				t.Fatalf("parseListOptions = %v, want %v", err, tt.err)
+			}
+			if err == nil && (got.Sort != tt.want.Sort || got.Desc != tt.want.Desc || got.Limit != tt.want.Limit ||
+				!got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) || got.After != nil) {
+				t.Errorf("parseListOptions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2026, 10, 19, 9, 30, 0, 123456000, time.UTC)
+	tests := []struct {
+		sort  string
+		value string
+		want  interface{}
+	}{
+		{SortCreated, created.Format(time.RFC3339Nano), created},
+		{SortUpdated, created.Format(time.RFC3339Nano), created},
+		{SortName, "Weekly, shop", "Weekly, shop"},
+		{SortPrice, "1250", 1250},
+	}
+	for _, tt := range tests {
+		for _, desc := range []bool{false, true} {
+			c := encodeCursor(cursor{Sort: tt.sort, Desc: desc, Value: tt.value, ID: 42})
+			q := Query{Sort: tt.sort, Desc: desc}
+			pos, err := decodeCursor(c, q)
+			if err != nil {
+				t.Fatalf("%s desc %v: %v", tt.sort, desc, err)
+			}
+			if pos.ID != 42 {
+				t.Errorf("%s: ID %d, want 42", tt.sort, pos.ID)
+			}
+			if tm, ok := tt.want.(time.Time); ok {
+				if got, ok := pos.Value.(time.Time); !ok || !got.Equal(tm) {
+					t.Errorf("%s: value %v, want %v", tt.sort, pos.Value, tm)
+				}
+			} else if pos.Value != tt.want {
+				t.Errorf("%s: value %#v, want %#v", tt.sort, pos.Value, tt.want)
+			}
+
+			// A cursor only continues the listing it was made for
+			if _, err := decodeCursor(c, Query{Sort: tt.sort, Desc: !desc}); err != pkg.ErrCursor {
+				t.Errorf("%s replayed with the other direction: %v", tt.sort, err)
+			}
+			for _, other := range []string{SortCreated, SortUpdated, SortName, SortPrice} {
+				if other == tt.sort {
+					continue
+				}
+				if _, err := decodeCursor(c, Query{Sort: other, Desc: desc}); err != pkg.ErrCursor {
+					t.Errorf("%s cursor replayed sorted by %s: %v", tt.sort, other, err)
+				}
+			}
+		}
+	}
+}
+
+func TestCursorMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"not base64", "!!!", SortCreated},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope")), SortCreated},
+		{"padded", encodeCursor(cursor{Sort: SortName, Value: "x"}) + "=", SortName},
+		{"bad time", encodeCursor(cursor{Sort: SortCreated, Value: "yesterday"}), SortCreated},
+		{"empty time", encodeCursor(cursor{Sort: SortCreated}), SortCreated},
+		{"bad price", encodeCursor(cursor{Sort: SortPrice, Value: "12.50"}), SortPrice},
+		{"unknown sort", encodeCursor(cursor{Sort: "id", Value: "1"}), SortCreated},
+	}
+	for _, tt := range tests {
+		if _, err := decodeCursor(tt.cursor, Query{Sort: tt.sort}); err != pkg.ErrCursor {
+			t.Errorf("%s: %v, want ErrCursor", tt.name, err)
+		}
+	}
+}
+
+// itemRepo lists the items of one cart in memory the way the database does
+// for the price sort.
+type itemRepo struct {
+	Repository
+	items []entities.CartItem
+}
+
+func (r *itemRepo) ListCartItems(cartID string, q Query) ([]entities.CartItem, error) {
+	items := append([]entities.CartItem(nil), r.items...)
+	less := func(a, b entities.CartItem) bool {
+		if a.ItemPrice != b.ItemPrice {
+			return a.ItemPrice < b.ItemPrice
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if q.Desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+	var page []entities.CartItem
+	for _, item := range items {
+		if q.After != nil {
+			after := entities.CartItem{ItemPrice: q.After.Value.(int)}
+			after.ID = q.After.ID
+			if !q.Desc && !less(after, item) || q.Desc && !less(item, after) {
+				continue
+			}
+		}
+		if len(page) == q.Limit {
+			break
+		}
+		page = append(page, item)
+	}
+	return page, nil
+}
+
+func (r *itemRepo) CartTotal(cartID string) (int, error) {
+	total := 0
+	for _, item := range r.items {
+		total += item.ItemPrice * item.ItemQuantity
+	}
+	return total, nil
+}
+
+func TestListCartItemsPages(t *testing.T) {
+	repo := &itemRepo{}
+	// Equal prices are told apart by the ID
+	for i, price := range []int{300, 100, 200, 100, 100, 300, 50} {
+		item := entities.CartItem{ItemName: fmt.Sprintf("item %d", i), ItemPrice: price, ItemQuantity: 1}
+		item.ID = uint(i + 1)
+		repo.items = append(repo.items, item)
+	}
+	s := &service{repo: repo}
+
+	for _, order := range []string{"asc", "desc"} {
+		var seen []uint
+		opts := ListOptions{Sort: SortPrice, Order: order, Limit: 2}
+		for pages := 0; ; pages++ {
+			if pages > len(repo.items) {
+				t.Fatalf("%s: paging does not end", order)
+			}
+			page, err := s.ListCartItems("cart", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 1150 {
+				t.Errorf("total %d, want the whole cart", page.Total)
+			}
+			for _, item := range page.Items {
+				seen = append(seen, item.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+		want := []uint{7, 2, 4, 5, 3, 1, 6}
+		if order == "desc" {
+			want = []uint{6, 1, 3, 5, 4, 2, 7}
+		}
+		if fmt.Sprint(seen) != fmt.Sprint(want) {
+			t.Errorf("%s: items %v, want %v", order, seen, want)
+		}
+	}
+}
+
+// TestListCartsPages runs against the database in TEST_DATABASE_URL.
+func TestListCartsPages(t *testing.T) {
+	db := testDB(t)
+	r := NewRepo(db)
+	const userID = "list-carts-test"
+	defer db.Unscoped().Where("user_id = ?", userID).Delete(&entities.Cart{})
+	for i, name := range []string{"b", "a", "c", "a", "b"} {
+		cart := &entities.Cart{UUID: fmt.Sprintf("list-carts-test-%d", i), CartName: name, UserID: userID}
+		if _, err := r.CreateCart(cart, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &service{repo: r}
+
+	for _, sortKey := range []string{SortCreated, SortUpdated, SortName, SortPrice} {
+		for _, order := range []string{"asc", "desc"} {
+			all, err := s.ListCarts(userID, ListOptions{Sort: sortKey, Order: order})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paged []string
+			opts := ListOptions{Sort: sortKey, Order: order, Limit: 2}
+			for {
+				page, err := s.ListCarts(userID, opts)
+				if err != nil {
+					t.Fatalf("%s %s: %v", sortKey, order, err)
+				}
+				for _, c := range page.Carts {
+					paged = append(paged, c.UUID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			var want []string
+			for _, c := range all.Carts {
+				want = append(want, c.UUID)
+			}
+			if len(want) != 5 || fmt.Sprint(paged) != fmt.Sprint(want) {
+				t.Errorf("%s %s: pages %v, want %v", sortKey, order, paged, want)
+			}
+		}
+	}
+}
@@ -1,18 +1,33 @@
 package cart
 
 import (
+	"fmt"
 	"github.com/jinzhu/gorm"
 	"github.com/rithikjain/quickscan-backend/pkg"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"sort"
+	"strings"
+	"time"
 )
 
 type Repository interface {
-	CreateCart(cart *entities.Cart) (*entities.Cart, error)
+	// CreateCart stores the cart together with its first items in a single
+	// transaction.
+	CreateCart(cart *entities.Cart, items []entities.CartItem) (*entities.Cart, error)
 
 	ChangeCartName(cartID string, name string) (*entities.Cart, error)
 
+	FindCart(cartID string) (*entities.Cart, error)
+
 	GetCarts(userID string) (*[]entities.Cart, error)
 
+	// FindCarts returns the live carts among cartIDs in one query.
+	FindCarts(cartIDs []string) (*[]entities.Cart, error)
+
+	// ListCarts returns up to q.Limit of the user's carts that match q.
+	ListCarts(userID string, q Query) ([]entities.Cart, error)
+
 	CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, error)
 
 	UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, error)
@@ -20,8 +35,95 @@ type Repository interface {
 	DeleteCartItem(cartItemID string) error
 
 	GetCartItems(cartID string) (*[]entities.CartItem, error)
+
+	// GetItemsOfCarts returns the items of every given cart in one query.
+	GetItemsOfCarts(cartIDs []string) (*[]entities.CartItem, error)
+
+	// ListCartItems returns up to q.Limit of the cart's items that match q.
+	// Query.Archived does not apply to items.
+	ListCartItems(cartID string, q Query) ([]entities.CartItem, error)
+
+	PurgeUserCarts(userID string) error
+
+	FindCartItem(cartItemID string) (*entities.CartItem, error)
+
+	// CreateOrder stores the order with its lines, takes the lines linked
+	// to the catalog off the store's stock and marks its cart as checked
+	// out in a single transaction. It fails with pkg.ErrCheckedOut when the
+	// cart was checked out in the meantime. Lines the stock did not cover
+	// record the shortfall and flag the order. The stock levels taken from
+	// are returned.
+	CreateOrder(order *entities.Order, cart *entities.Cart, lines []entities.OrderLine) (*entities.Order, []entities.StockLevel, error)
+
+	GetOrderLines(orderID string) (*[]entities.OrderLine, error)
+
+	GetOrders(userID string) (*[]entities.Order, error)
+
+	FindOrder(orderID string) (*entities.Order, error)
+
+	CreateTemplate(template *entities.CartTemplate, items []entities.CartTemplateItem) (*entities.CartTemplate, error)
+
+	FindTemplate(templateID string) (*entities.CartTemplate, error)
+
+	GetTemplates(userID string) (*[]entities.CartTemplate, error)
+
+	GetTemplateItems(templateID string) (*[]entities.CartTemplateItem, error)
+
+	DeleteTemplate(template *entities.CartTemplate) error
+
+	SaveCart(cart *entities.Cart) (*entities.Cart, error)
+
+	// SoftDeleteCart moves the cart and its items to the trash, stamping
+	// them with the same deletion time so a restore can tell them apart
+	// from items that were removed earlier.
+	SoftDeleteCart(cart *entities.Cart) error
+
+	FindDeletedCart(cartID string) (*entities.Cart, error)
+
+	GetDeletedCarts(userID string) (*[]entities.Cart, error)
+
+	RestoreCart(cart *entities.Cart) error
+
+	PurgeCart(cartID string) error
+
+	// PurgeDeletedCarts permanently removes carts trashed before the given
+	// time, except checked out ones, and returns how many were removed.
+	PurgeDeletedCarts(before time.Time) (int, error)
+
+	// CartTotal sums price times quantity over the cart's live items.
+	CartTotal(cartID string) (int, error)
+
+	// OrdersTotal sums the totals of the user's orders completed in
+	// [from, to).
+	OrdersTotal(userID string, from, to time.Time) (int, error)
+
+	FindMonthlyBudget(userID string) (*entities.MonthlyBudget, error)
+
+	SaveMonthlyBudget(budget *entities.MonthlyBudget) (*entities.MonthlyBudget, error)
+
+	// RecordBudgetAlert stores the alert unless it was recorded before and
+	// reports whether it was new.
+	RecordBudgetAlert(alert *entities.BudgetAlert) (bool, error)
 }
 
+// Columns that listings sort by, keyed by sort key.
+var (
+	cartSortColumns = map[string]string{
+		SortCreated: "created_at",
+		SortUpdated: "updated_at",
+		SortName:    "cart_name",
+		SortPrice:   "(SELECT COALESCE(SUM(i.item_price * i.item_quantity), 0) FROM cart_items i WHERE i.cart_id = carts.uuid AND i.deleted_at IS NULL)",
+	}
+	itemSortColumns = map[string]string{
+		SortCreated: "created_at",
+		SortUpdated: "updated_at",
+		SortName:    "item_name",
+		SortPrice:   "item_price",
+	}
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type repo struct {
 	DB *gorm.DB
 }
@@ -32,9 +134,20 @@ func NewRepo(db *gorm.DB) Repository {
 	}
 }
 
-func (r *repo) CreateCart(cart *entities.Cart) (*entities.Cart, error) {
-	result := r.DB.Create(cart)
-	if result.Error != nil {
+func (r *repo) CreateCart(cart *entities.Cart, items []entities.CartItem) (*entities.Cart, error) {
+	tx := r.DB.Begin()
+	if err := tx.Create(cart).Error; err != nil {
+		tx.Rollback()
+		return nil, pkg.ErrDatabase
+	}
+	for i := range items {
+		items[i].CartID = cart.UUID
+		if err := tx.Create(&items[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, pkg.ErrDatabase
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
 		return nil, pkg.ErrDatabase
 	}
 	return cart, nil
@@ -54,6 +167,19 @@ func (r *repo) ChangeCartName(cartID string, name string) (*entities.Cart, error
 	return cart, nil
 }
 
+func (r *repo) FindCart(cartID string) (*entities.Cart, error) {
+	cart := &entities.Cart{}
+	result := r.DB.Where("uuid = ?", cartID).First(cart)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return cart, nil
+}
+
 func (r *repo) GetCarts(userID string) (*[]entities.Cart, error) {
 	var carts []entities.Cart
 	err := r.DB.Where("user_id = ?", userID).Find(&carts).Error
@@ -63,6 +189,34 @@ func (r *repo) GetCarts(userID string) (*[]entities.Cart, error) {
 	return &carts, nil
 }
 
+func (r *repo) FindCarts(cartIDs []string) (*[]entities.Cart, error) {
+	var carts []entities.Cart
+	err := r.DB.Where("uuid IN (?)", cartIDs).Find(&carts).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &carts, nil
+}
+
+func (r *repo) ListCarts(userID string, q Query) ([]entities.Cart, error) {
+	db := r.DB.Where("user_id = ?", userID)
+	if q.Search != "" {
+		db = db.Where("cart_name ILIKE ?", "%"+likeEscaper.Replace(q.Search)+"%")
+	}
+	if q.Archived != nil && *q.Archived {
+		db = db.Where("archived_at IS NOT NULL")
+	} else if q.Archived != nil {
+		db = db.Where("archived_at IS NULL")
+	}
+
+	var carts []entities.Cart
+	err := paginate(db, q, cartSortColumns[q.Sort]).Find(&carts).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return carts, nil
+}
+
 func (r *repo) CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, error) {
 	result := r.DB.Create(cartItem)
 	if result.Error != nil {
@@ -106,3 +260,427 @@ func (r *repo) GetCartItems(cartID string) (*[]entities.CartItem, error) {
 	}
 	return &cartItems, nil
 }
+
+func (r *repo) GetItemsOfCarts(cartIDs []string) (*[]entities.CartItem, error) {
+	var cartItems []entities.CartItem
+	err := r.DB.Where("cart_id IN (?)", cartIDs).Order("id").Find(&cartItems).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &cartItems, nil
+}
+
+func (r *repo) ListCartItems(cartID string, q Query) ([]entities.CartItem, error) {
+	db := r.DB.Where("cart_id = ?", cartID)
+	if q.Search != "" {
+		db = db.Where("item_name ILIKE ?", "%"+likeEscaper.Replace(q.Search)+"%")
+	}
+
+	var items []entities.CartItem
+	err := paginate(db, q, itemSortColumns[q.Sort]).Find(&items).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return items, nil
+}
+
+// paginate applies the creation range, the keyset position, the ordering
+// and the limit of q. The ID breaks ties so rows with equal sort values are
+// neither repeated nor skipped between pages.
+func paginate(db *gorm.DB, q Query, column string) *gorm.DB {
+	if !q.From.IsZero() {
+		db = db.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		db = db.Where("created_at < ?", q.To)
+	}
+	dir, cmp := "ASC", ">"
+	if q.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.After != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, cmp), q.After.Value, q.After.ID)
+	}
+	return db.Order(fmt.Sprintf("%s %s, id %s", column, dir, dir)).Limit(q.Limit)
+}
+
+func (r *repo) PurgeUserCarts(userID string) error {
+	tx := r.DB.Begin()
+	var cartIDs []string
+	if err := tx.Unscoped().Model(&entities.Cart{}).Where("user_id = ?", userID).Pluck("uuid", &cartIDs).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("cart_id IN (?)", cartIDs).Delete(&entities.CartItem{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.Cart{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("order_id IN (?)", tx.Unscoped().Model(&entities.Order{}).Where("user_id = ?", userID).Select("uuid").SubQuery()).Delete(&entities.OrderLine{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.Order{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	var templateIDs []string
+	if err := tx.Unscoped().Model(&entities.CartTemplate{}).Where("user_id = ?", userID).Pluck("uuid", &templateIDs).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("template_id IN (?)", templateIDs).Delete(&entities.CartTemplateItem{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.CartTemplate{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.MonthlyBudget{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.BudgetAlert{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) FindCartItem(cartItemID string) (*entities.CartItem, error) {
+	cartItem := &entities.CartItem{}
+	result := r.DB.Where("uuid = ?", cartItemID).First(cartItem)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return cartItem, nil
+}
+
+func (r *repo) CreateOrder(order *entities.Order, cart *entities.Cart, lines []entities.OrderLine) (*entities.Order, []entities.StockLevel, error) {
+	tx := r.DB.Begin()
+	// Only one of concurrent checkouts of the cart gets to mark it
+	result := tx.Model(&entities.Cart{}).
+		Where("uuid = ? AND checked_out_at IS NULL", cart.UUID).
+		UpdateColumn("checked_out_at", order.CompletedAt)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, nil, pkg.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, nil, pkg.ErrCheckedOut
+	}
+
+	var levels []entities.StockLevel
+	if order.StoreID != "" {
+		// Stock rows are locked in product order, so that concurrent
+		// checkouts cannot deadlock on them
+		byProduct := make([]int, 0, len(lines))
+		for i, line := range lines {
+			if line.ProductID != "" && line.ItemQuantity > 0 {
+				byProduct = append(byProduct, i)
+			}
+		}
+		sort.SliceStable(byProduct, func(a, b int) bool {
+			return lines[byProduct[a]].ProductID < lines[byProduct[b]].ProductID
+		})
+		for _, i := range byProduct {
+			stock, shortfall, err := inventory.Adjust(tx, order.StoreID, lines[i].ProductID, -lines[i].ItemQuantity)
+			if err != nil {
+				tx.Rollback()
+				return nil, nil, err
+			}
+			if shortfall > 0 {
+				lines[i].StockShortfall = shortfall
+				order.StockShortfall = true
+			}
+			levels = append(levels, *stock)
+		}
+	}
+
+	if err := tx.Create(order).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, pkg.ErrDatabase
+	}
+	for i := range lines {
+		lines[i].OrderID = order.UUID
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, pkg.ErrDatabase
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, pkg.ErrDatabase
+	}
+	cart.CheckedOutAt = &order.CompletedAt
+	return order, levels, nil
+}
+
+func (r *repo) GetOrderLines(orderID string) (*[]entities.OrderLine, error) {
+	var lines []entities.OrderLine
+	err := r.DB.Where("order_id = ?", orderID).Order("id").Find(&lines).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &lines, nil
+}
+
+func (r *repo) GetOrders(userID string) (*[]entities.Order, error) {
+	var orders []entities.Order
+	err := r.DB.Where("user_id = ?", userID).Order("completed_at desc").Find(&orders).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &orders, nil
+}
+
+func (r *repo) FindOrder(orderID string) (*entities.Order, error) {
+	order := &entities.Order{}
+	result := r.DB.Where("uuid = ?", orderID).First(order)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return order, nil
+}
+
+func (r *repo) CreateTemplate(template *entities.CartTemplate, items []entities.CartTemplateItem) (*entities.CartTemplate, error) {
+	tx := r.DB.Begin()
+	if err := tx.Create(template).Error; err != nil {
+		tx.Rollback()
+		return nil, pkg.ErrDatabase
+	}
+	for i := range items {
+		items[i].TemplateID = template.UUID
+		if err := tx.Create(&items[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, pkg.ErrDatabase
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return template, nil
+}
+
+func (r *repo) FindTemplate(templateID string) (*entities.CartTemplate, error) {
+	template := &entities.CartTemplate{}
+	result := r.DB.Where("uuid = ?", templateID).First(template)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return template, nil
+}
+
+func (r *repo) GetTemplates(userID string) (*[]entities.CartTemplate, error) {
+	var templates []entities.CartTemplate
+	err := r.DB.Where("user_id = ?", userID).Order("name").Find(&templates).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &templates, nil
+}
+
+func (r *repo) GetTemplateItems(templateID string) (*[]entities.CartTemplateItem, error) {
+	var items []entities.CartTemplateItem
+	err := r.DB.Where("template_id = ?", templateID).Order("id").Find(&items).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &items, nil
+}
+
+func (r *repo) DeleteTemplate(template *entities.CartTemplate) error {
+	tx := r.DB.Begin()
+	if err := tx.Where("template_id = ?", template.UUID).Delete(&entities.CartTemplateItem{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Delete(template).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) SaveCart(cart *entities.Cart) (*entities.Cart, error) {
+	if err := r.DB.Save(cart).Error; err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return cart, nil
+}
+
+func (r *repo) SoftDeleteCart(cart *entities.Cart) error {
+	now := time.Now().Truncate(time.Microsecond)
+	tx := r.DB.Begin()
+	if err := tx.Model(&entities.CartItem{}).Where("cart_id = ?", cart.UUID).UpdateColumn("deleted_at", now).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Model(cart).UpdateColumn("deleted_at", now).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	cart.DeletedAt = &now
+	return nil
+}
+
+func (r *repo) FindDeletedCart(cartID string) (*entities.Cart, error) {
+	cart := &entities.Cart{}
+	result := r.DB.Unscoped().Where("uuid = ? AND deleted_at IS NOT NULL", cartID).First(cart)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return cart, nil
+}
+
+func (r *repo) GetDeletedCarts(userID string) (*[]entities.Cart, error) {
+	var carts []entities.Cart
+	err := r.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at desc").Find(&carts).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &carts, nil
+}
+
+func (r *repo) RestoreCart(cart *entities.Cart) error {
+	tx := r.DB.Begin()
+	if err := tx.Unscoped().Model(&entities.CartItem{}).Where("cart_id = ? AND deleted_at = ?", cart.UUID, cart.DeletedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Model(cart).UpdateColumn("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	cart.DeletedAt = nil
+	return nil
+}
+
+func (r *repo) PurgeCart(cartID string) error {
+	tx := r.DB.Begin()
+	if err := tx.Unscoped().Where("cart_id = ?", cartID).Delete(&entities.CartItem{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("uuid = ?", cartID).Delete(&entities.Cart{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) PurgeDeletedCarts(before time.Time) (int, error) {
+	tx := r.DB.Begin()
+	var cartIDs []string
+	if err := tx.Unscoped().Model(&entities.Cart{}).Where("deleted_at < ? AND checked_out_at IS NULL", before).Pluck("uuid", &cartIDs).Error; err != nil {
+		tx.Rollback()
+		return 0, pkg.ErrDatabase
+	}
+	if len(cartIDs) == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+	if err := tx.Unscoped().Where("cart_id IN (?)", cartIDs).Delete(&entities.CartItem{}).Error; err != nil {
+		tx.Rollback()
+		return 0, pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("uuid IN (?)", cartIDs).Delete(&entities.Cart{}).Error; err != nil {
+		tx.Rollback()
+		return 0, pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return 0, pkg.ErrDatabase
+	}
+	return len(cartIDs), nil
+}
+
+func (r *repo) CartTotal(cartID string) (int, error) {
+	var total int
+	err := r.DB.Model(&entities.CartItem{}).
+		Where("cart_id = ?", cartID).
+		Select("COALESCE(SUM(item_price * item_quantity), 0)").
+		Row().Scan(&total)
+	if err != nil {
+		return 0, pkg.ErrDatabase
+	}
+	return total, nil
+}
+
+func (r *repo) OrdersTotal(userID string, from, to time.Time) (int, error) {
+	var total int
+	err := r.DB.Model(&entities.Order{}).
+		Where("user_id = ? AND completed_at >= ? AND completed_at < ?", userID, from, to).
+		Select("COALESCE(SUM(total), 0)").
+		Row().Scan(&total)
+	if err != nil {
+		return 0, pkg.ErrDatabase
+	}
+	return total, nil
+}
+
+func (r *repo) FindMonthlyBudget(userID string) (*entities.MonthlyBudget, error) {
+	budget := &entities.MonthlyBudget{}
+	result := r.DB.Where("user_id = ?", userID).First(budget)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return budget, nil
+}
+
+func (r *repo) SaveMonthlyBudget(budget *entities.MonthlyBudget) (*entities.MonthlyBudget, error) {
+	if err := r.DB.Save(budget).Error; err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return budget, nil
+}
+
+func (r *repo) RecordBudgetAlert(alert *entities.BudgetAlert) (bool, error) {
+	now := time.Now()
+	result := r.DB.Exec(`INSERT INTO budget_alerts (created_at, updated_at, user_id, scope, period, threshold)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id, scope, period, threshold) DO NOTHING`,
+		now, now, alert.UserID, alert.Scope, alert.Period, alert.Threshold)
+	if result.Error != nil {
+		return false, pkg.ErrDatabase
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,103 @@
+package cart
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"github.com/rithikjain/quickscan-backend/pkg/migrate"
+)
+
+// testDB connects to the database in TEST_DATABASE_URL and migrates it. The
+// test is skipped without one.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := migrate.New(db.DB(), migrate.All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestCreateOrderTakesStock(t *testing.T) {
+	db := testDB(t)
+	r := NewRepo(db)
+	stock := inventory.NewRepo(db)
+	const storeID = "checkout-stock-test"
+	defer db.Unscoped().Where("store_id = ?", storeID).Delete(&entities.StockLevel{})
+	defer db.Unscoped().Where("store_id = ?", storeID).Delete(&entities.Order{})
+	if _, err := stock.AdjustStock(storeID, "apple", 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stock.AdjustStock(storeID, "pear", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	cart, err := r.CreateCart(&entities.Cart{UUID: "checkout-stock-cart", UserID: "checkout-stock-user", StoreID: storeID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Unscoped().Delete(cart)
+	order := &entities.Order{UUID: "checkout-stock-order", CartID: cart.UUID, StoreID: storeID, CompletedAt: time.Now()}
+	lines := []entities.OrderLine{
+		{ProductID: "pear", ItemQuantity: 3},
+		{ProductID: "apple", ItemQuantity: 2},
+		{ItemName: "loose item", ItemQuantity: 1},
+	}
+	defer db.Unscoped().Where("order_id = ?", order.UUID).Delete(&entities.OrderLine{})
+	order, levels, err := r.CreateOrder(order, cart, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 {
+		t.Errorf("took stock from %d levels, want 2", len(levels))
+	}
+	if !order.StockShortfall {
+		t.Error("order not flagged for the pears it took without stock")
+	}
+
+	want := map[string]int{"apple": 3, "pear": 0}
+	for product, quantity := range want {
+		level, err := stock.FindStock(storeID, product)
+		if err != nil || level.Quantity != quantity {
+			t.Errorf("%s stock %+v, %v, want %d", product, level, err, quantity)
+		}
+	}
+	stored, err := r.GetOrderLines(order.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortfalls := map[string]int{}
+	for _, line := range *stored {
+		shortfalls[line.ProductID] = line.StockShortfall
+	}
+	if shortfalls["pear"] != 2 || shortfalls["apple"] != 0 {
+		t.Errorf("shortfalls %v, want 2 pears", shortfalls)
+	}
+
+	// A second checkout of the cart takes nothing
+	again := &entities.Order{UUID: "checkout-stock-order-2", CartID: cart.UUID, StoreID: storeID, CompletedAt: time.Now()}
+	if _, _, err := r.CreateOrder(again, cart, []entities.OrderLine{{ProductID: "apple", ItemQuantity: 1}}); err != pkg.ErrCheckedOut {
+		t.Fatalf("second checkout = %v, want ErrCheckedOut", err)
+	}
+	if level, _ := stock.FindStock(storeID, "apple"); level.Quantity != 3 {
+		t.Errorf("refused checkout took stock, %d apples left", level.Quantity)
+	}
+}
@@ -1,72 +1,470 @@
 package cart
 
 import (
+	"fmt"
 	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/catalog"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/inventory"
+	"github.com/rithikjain/quickscan-backend/pkg/notify"
+	"github.com/rithikjain/quickscan-backend/pkg/store"
+	"log"
+	"strings"
+	"time"
 )
 
+// CartUpdate holds the cart fields a user can change. Nil fields are left
+// untouched.
+type CartUpdate struct {
+	CartName *string
+	Budget   *int
+	Archived *bool
+}
+
 type Service interface {
-	CreateCart(cart *entities.Cart) (*entities.Cart, error)
+	// CreateCart starts a new cart. Carts tied to a store can only be
+	// started from inside the store's geofence.
+	CreateCart(cart *entities.Cart, at *entities.LatLng) (*entities.Cart, error)
 
 	ChangeCartName(cartID string, name string) (*entities.Cart, error)
 
+	// UpdateCart applies every field of the update to the user's cart in a
+	// single save, or none of them when one is not valid.
+	UpdateCart(cartID, userID string, update *CartUpdate) (*entities.Cart, error)
+
+	GetCart(cartID string) (*entities.Cart, error)
+
 	GetCarts(userID string) (*[]entities.Cart, error)
 
-	CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, error)
+	// GetCartsByIDs returns several carts at once, keyed by cart ID. Carts
+	// that do not exist or are in the trash have no entry.
+	GetCartsByIDs(cartIDs []string) (map[string]*entities.Cart, error)
+
+	// ListCarts returns one page of the user's carts.
+	ListCarts(userID string, opts ListOptions) (*CartPage, error)
 
-	UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, error)
+	// CreateCartItem adds an item to a cart. Items linked to a catalog
+	// product take their name and price from the catalog of the cart's
+	// store instead of the values sent by the client. The returned report
+	// reflects the cart and monthly budgets after the change. Invalid
+	// items fail with a *pkg.ValidationError.
+	CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, *BudgetReport, error)
+
+	UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, *BudgetReport, error)
 
 	DeleteCartItem(cartItemID string) error
 
 	GetCartItems(cartID string) (*[]entities.CartItem, error)
+
+	// GetItemsOfCarts returns the items of several carts at once, keyed by
+	// cart ID. Every requested cart has an entry, empty when it has no items.
+	GetItemsOfCarts(cartIDs []string) (map[string][]entities.CartItem, error)
+
+	// ListCartItems returns one page of the cart's items.
+	ListCartItems(cartID string, opts ListOptions) (*ItemPage, error)
+
+	GetCartItem(cartItemID string) (*entities.CartItem, error)
+
+	// DeleteUserData permanently removes every cart, order, template and
+	// budget of the user along with their items, including soft deleted
+	// ones.
+	DeleteUserData(userID string) error
+
+	// Checkout turns the cart into a completed order. Carts of a store can
+	// only be checked out while the store is open.
+	Checkout(cartID, userID string) (*entities.Order, error)
+
+	GetOrders(userID string) (*[]entities.Order, error)
+
+	GetOrder(orderID, userID string) (*entities.Order, error)
+
+	SaveAsTemplate(cartID, userID, name string) (*TemplateDetails, error)
+
+	GetTemplate(templateID, userID string) (*TemplateDetails, error)
+
+	GetTemplates(userID string) (*[]entities.CartTemplate, error)
+
+	DeleteTemplate(templateID, userID string) error
+
+	// CreateFromTemplate starts a cart with the template's items, priced at
+	// storeID or, when empty, the store the template was saved from.
+	CreateFromTemplate(templateID, userID, storeID string, at *entities.LatLng) (*entities.Cart, []LineChange, error)
+
+	// Reorder starts a cart with the items of a previous order, priced like
+	// CreateFromTemplate.
+	Reorder(orderID, userID, storeID string, at *entities.LatLng) (*entities.Cart, []LineChange, error)
+
+	// ArchiveCart sets or clears the cart's archived flag. Archived carts
+	// stay readable but are kept out of the way of active shopping.
+	ArchiveCart(cartID, userID string, archived bool) (*entities.Cart, error)
+
+	// DeleteCart moves the cart and its items to the trash, from where they
+	// can be restored until they are purged. Checked out carts belong to
+	// their order and can be archived but not deleted.
+	DeleteCart(cartID, userID string) error
+
+	GetTrash(userID string) (*[]entities.Cart, error)
+
+	RestoreCart(cartID, userID string) (*entities.Cart, error)
+
+	// PurgeCart permanently removes a cart that is in the trash.
+	PurgeCart(cartID, userID string) error
+
+	// PurgeTrash permanently removes every cart that has been in the trash
+	// for longer than retention. Checked out carts are never purged.
+	PurgeTrash(retention time.Duration) (int, error)
+
+	// SetCartBudget sets the amount the user plans to spend on the cart, 0
+	// removes the budget.
+	SetCartBudget(cartID, userID string, amount int) (*entities.Cart, error)
+
+	// SetMonthlyBudget sets the amount the user plans to spend per calendar
+	// month, 0 removes the budget. Months run in timezone, an empty one
+	// keeps the timezone set before or UTC.
+	SetMonthlyBudget(userID string, amount int, timezone string) (*entities.MonthlyBudget, error)
+
+	// GetBudgetReport reports the user's monthly budget and, when cartID is
+	// not empty, the budget of that cart.
+	GetBudgetReport(cartID, userID string) (*BudgetReport, error)
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	stores   store.Service
+	products catalog.Service
+	stock    inventory.Service
+	notifier notify.Notifier
 }
 
-func NewService(r Repository) Service {
+func NewService(r Repository, stores store.Service, products catalog.Service, stock inventory.Service, notifier notify.Notifier) Service {
 	return &service{
-		repo: r,
+		repo:     r,
+		stores:   stores,
+		products: products,
+		stock:    stock,
+		notifier: notifier,
+	}
+}
+
+func (s *service) CreateCart(cart *entities.Cart, at *entities.LatLng) (*entities.Cart, error) {
+	if err := s.prepareCart(cart, at); err != nil {
+		return nil, err
 	}
+	return s.repo.CreateCart(cart, nil)
 }
 
-func (s *service) CreateCart(cart *entities.Cart) (*entities.Cart, error) {
+// prepareCart checks that a cart at a store is started inside it and gives
+// the cart its ID.
+func (s *service) prepareCart(cart *entities.Cart, at *entities.LatLng) error {
+	if cart.StoreID != "" {
+		st, err := s.stores.GetStore(cart.StoreID)
+		if err != nil {
+			return err
+		}
+		if at == nil {
+			return pkg.ErrNoLocation
+		}
+		if !s.stores.IsInside(st, *at) {
+			return pkg.ErrOutsideStore
+		}
+	}
 	uuid, err := uuid2.NewV4()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	cart.UUID = uuid.String()
-
-	return s.repo.CreateCart(cart)
+	return nil
 }
 
 func (s *service) ChangeCartName(cartID string, name string) (*entities.Cart, error) {
 	return s.repo.ChangeCartName(cartID, name)
 }
 
+func (s *service) UpdateCart(cartID, userID string, update *CartUpdate) (*entities.Cart, error) {
+	if update.Budget != nil && *update.Budget < 0 {
+		return nil, pkg.ErrBudget
+	}
+	cart, err := s.ownCart(cartID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if update.CartName != nil {
+		cart.CartName = *update.CartName
+	}
+	if update.Budget != nil {
+		cart.Budget = *update.Budget
+	}
+	if update.Archived != nil && *update.Archived != (cart.ArchivedAt != nil) {
+		if *update.Archived {
+			now := time.Now()
+			cart.ArchivedAt = &now
+		} else {
+			cart.ArchivedAt = nil
+		}
+	}
+	return s.repo.SaveCart(cart)
+}
+
+func (s *service) GetCart(cartID string) (*entities.Cart, error) {
+	return s.repo.FindCart(cartID)
+}
+
 func (s *service) GetCarts(userID string) (*[]entities.Cart, error) {
 	return s.repo.GetCarts(userID)
 }
 
-func (s *service) CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, error) {
-	uuid, err := uuid2.NewV4()
+func (s *service) GetCartsByIDs(cartIDs []string) (map[string]*entities.Cart, error) {
+	byID := make(map[string]*entities.Cart, len(cartIDs))
+	if len(cartIDs) == 0 {
+		return byID, nil
+	}
+	carts, err := s.repo.FindCarts(cartIDs)
 	if err != nil {
 		return nil, err
 	}
+	for i := range *carts {
+		byID[(*carts)[i].UUID] = &(*carts)[i]
+	}
+	return byID, nil
+}
+
+// Limits of a cart item, whichever API it comes through.
+const (
+	MaxItemQuantity = 1000
+	MaxItemPrice    = 100000000
+)
+
+// validateItem checks the fields of a new item. Items linked to a catalog
+// product take their name and price from the catalog, the others need both.
+func validateItem(item *entities.CartItem) error {
+	var fields []pkg.FieldError
+	if item.ProductID == "" && strings.TrimSpace(item.ItemName) == "" {
+		fields = append(fields, pkg.FieldError{Field: "item_name", Code: "required", Message: "is required when product_id is not set"})
+	}
+	if item.ProductID == "" && (item.ItemPrice < 0 || item.ItemPrice > MaxItemPrice) {
+		fields = append(fields, pkg.FieldError{Field: "item_price", Code: "range", Message: fmt.Sprintf("must be between 0 and %d", MaxItemPrice)})
+	}
+	if field := validateQuantity(item.ItemQuantity); field != nil {
+		fields = append(fields, *field)
+	}
+	if len(fields) > 0 {
+		return &pkg.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateQuantity(quantity int) *pkg.FieldError {
+	if quantity < 1 || quantity > MaxItemQuantity {
+		return &pkg.FieldError{Field: "item_quantity", Code: "range", Message: fmt.Sprintf("must be between 1 and %d", MaxItemQuantity)}
+	}
+	return nil
+}
+
+func (s *service) CreateCartItem(cartItem *entities.CartItem) (*entities.CartItem, *BudgetReport, error) {
+	if err := validateItem(cartItem); err != nil {
+		return nil, nil, err
+	}
+	cart, err := s.repo.FindCart(cartItem.CartID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cart.CheckedOutAt != nil {
+		return nil, nil, pkg.ErrCheckedOut
+	}
+	if cartItem.ProductID != "" {
+		if cart.StoreID == "" {
+			return nil, nil, pkg.ErrNotAllowed
+		}
+		product, err := s.products.GetStoreProduct(cart.StoreID, cartItem.ProductID)
+		if err != nil {
+			return nil, nil, err
+		}
+		cartItem.ItemName = product.Name
+		cartItem.ItemPrice = product.Price
+		if cartItem.ItemImageUrl == "" {
+			cartItem.ItemImageUrl = product.ImageUrl
+		}
+	}
+
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, nil, err
+	}
 	cartItem.UUID = uuid.String()
 
-	return s.repo.CreateCartItem(cartItem)
+	item, err := s.repo.CreateCartItem(cartItem)
+	if err != nil {
+		return nil, nil, err
+	}
+	report, err := s.budgetReport(cart, item.ItemPrice*item.ItemQuantity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, report, nil
 }
 
-func (s *service) UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, error) {
-	return s.repo.UpdateCartItemCount(cartItemID, newCount)
+func (s *service) UpdateCartItemCount(cartItemID string, newCount int) (*entities.CartItem, *BudgetReport, error) {
+	if field := validateQuantity(newCount); field != nil {
+		return nil, nil, &pkg.ValidationError{Fields: []pkg.FieldError{*field}}
+	}
+	item, cart, err := s.editableItem(cartItemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldCount := item.ItemQuantity
+
+	item, err = s.repo.UpdateCartItemCount(cartItemID, newCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	report, err := s.budgetReport(cart, item.ItemPrice*(newCount-oldCount))
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, report, nil
 }
 
 func (s *service) DeleteCartItem(cartItemID string) error {
+	if _, _, err := s.editableItem(cartItemID); err != nil {
+		return err
+	}
 	return s.repo.DeleteCartItem(cartItemID)
 }
 
+// editableItem fetches an item along with its cart, rejecting items of
+// checked out carts.
+func (s *service) editableItem(cartItemID string) (*entities.CartItem, *entities.Cart, error) {
+	item, err := s.repo.FindCartItem(cartItemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	cart, err := s.repo.FindCart(item.CartID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cart.CheckedOutAt != nil {
+		return nil, nil, pkg.ErrCheckedOut
+	}
+	return item, cart, nil
+}
+
 func (s *service) GetCartItems(cartID string) (*[]entities.CartItem, error) {
 	return s.repo.GetCartItems(cartID)
 }
+
+func (s *service) GetItemsOfCarts(cartIDs []string) (map[string][]entities.CartItem, error) {
+	byCart := make(map[string][]entities.CartItem, len(cartIDs))
+	for _, id := range cartIDs {
+		byCart[id] = []entities.CartItem{}
+	}
+	if len(cartIDs) == 0 {
+		return byCart, nil
+	}
+	items, err := s.repo.GetItemsOfCarts(cartIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range *items {
+		byCart[item.CartID] = append(byCart[item.CartID], item)
+	}
+	return byCart, nil
+}
+
+func (s *service) GetCartItem(cartItemID string) (*entities.CartItem, error) {
+	return s.repo.FindCartItem(cartItemID)
+}
+
+func (s *service) DeleteUserData(userID string) error {
+	return s.repo.PurgeUserCarts(userID)
+}
+
+func (s *service) Checkout(cartID, userID string) (*entities.Order, error) {
+	cart, err := s.repo.FindCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	if cart.CheckedOutAt != nil {
+		return nil, pkg.ErrCheckedOut
+	}
+
+	items, err := s.repo.GetCartItems(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if len(*items) == 0 {
+		return nil, pkg.ErrEmptyCart
+	}
+
+	now := time.Now()
+	order := &entities.Order{
+		CartID:      cart.UUID,
+		CartName:    cart.CartName,
+		UserID:      cart.UserID,
+		StoreID:     cart.StoreID,
+		Status:      entities.OrderStatusCompleted,
+		CompletedAt: now,
+	}
+	if cart.StoreID != "" {
+		st, err := s.stores.GetStore(cart.StoreID)
+		if err != nil {
+			return nil, err
+		}
+		status, err := s.stores.OpenStatus(st, now)
+		if err != nil {
+			return nil, err
+		}
+		if !status.Open {
+			return nil, pkg.ErrStoreClosed
+		}
+		order.Currency = st.Currency
+	}
+	lines := make([]entities.OrderLine, len(*items))
+	for i, item := range *items {
+		order.Total += item.ItemPrice * item.ItemQuantity
+		order.ItemCount += item.ItemQuantity
+		lines[i] = entities.OrderLine{
+			ProductID:    item.ProductID,
+			ItemName:     item.ItemName,
+			ItemPrice:    item.ItemPrice,
+			ItemQuantity: item.ItemQuantity,
+			ItemImageUrl: item.ItemImageUrl,
+		}
+	}
+
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	order.UUID = uuid.String()
+
+	// The shopper has the items in hand, stock the records do not cover
+	// flags the order for the stock team instead of failing the checkout.
+	order, levels, err := s.repo.CreateOrder(order, cart, lines)
+	if err != nil {
+		return nil, err
+	}
+	if order.StockShortfall {
+		log.Printf("cart: order %s took more stock than recorded", order.UUID)
+	}
+	s.stock.StockTaken(levels)
+	return order, nil
+}
+
+func (s *service) GetOrders(userID string) (*[]entities.Order, error) {
+	return s.repo.GetOrders(userID)
+}
+
+func (s *service) GetOrder(orderID, userID string) (*entities.Order, error) {
+	order, err := s.repo.FindOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	return order, nil
+}
@@ -0,0 +1,238 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"log"
+	"strings"
+)
+
+// Line statuses reported when a cart is rebuilt from a template or order.
+const (
+	LineUnchanged    = "unchanged"
+	LinePriceChanged = "price_changed"
+	LineDiscontinued = "discontinued"
+	LineNotInCatalog = "not_in_catalog"
+)
+
+// LineChange describes how one line came out of re-pricing against the
+// current catalog. Discontinued lines are not added to the new cart.
+type LineChange struct {
+	ProductID string `json:"product_id"`
+	ItemName  string `json:"item_name"`
+	Quantity  int    `json:"quantity"`
+	Status    string `json:"status"`
+	OldPrice  int    `json:"old_price"`
+	NewPrice  int    `json:"new_price"`
+}
+
+// TemplateDetails is a template together with its lines.
+type TemplateDetails struct {
+	entities.CartTemplate
+	Items []entities.CartTemplateItem `json:"items"`
+}
+
+func (s *service) SaveAsTemplate(cartID, userID, name string) (*TemplateDetails, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, pkg.ErrName
+	}
+	cart, err := s.repo.FindCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	items, err := s.repo.GetCartItems(cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]entities.CartTemplateItem, len(*items))
+	for i, item := range *items {
+		lines[i] = entities.CartTemplateItem{
+			ProductID:    item.ProductID,
+			ItemName:     item.ItemName,
+			ItemPrice:    item.ItemPrice,
+			ItemQuantity: item.ItemQuantity,
+			ItemImageUrl: item.ItemImageUrl,
+		}
+	}
+
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.repo.CreateTemplate(&entities.CartTemplate{
+		UUID:    uuid.String(),
+		UserID:  userID,
+		Name:    name,
+		StoreID: cart.StoreID,
+	}, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateDetails{CartTemplate: *template, Items: lines}, nil
+}
+
+func (s *service) GetTemplate(templateID, userID string) (*TemplateDetails, error) {
+	template, err := s.repo.FindTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	items, err := s.repo.GetTemplateItems(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateDetails{CartTemplate: *template, Items: *items}, nil
+}
+
+func (s *service) GetTemplates(userID string) (*[]entities.CartTemplate, error) {
+	return s.repo.GetTemplates(userID)
+}
+
+func (s *service) DeleteTemplate(templateID, userID string) error {
+	template, err := s.repo.FindTemplate(templateID)
+	if err != nil {
+		return err
+	}
+	if template.UserID != userID {
+		return pkg.ErrForbidden
+	}
+	return s.repo.DeleteTemplate(template)
+}
+
+func (s *service) CreateFromTemplate(templateID, userID, storeID string, at *entities.LatLng) (*entities.Cart, []LineChange, error) {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if storeID == "" {
+		storeID = template.StoreID
+	}
+
+	lines := make([]entities.CartItem, len(template.Items))
+	for i, item := range template.Items {
+		lines[i] = entities.CartItem{
+			ProductID:    item.ProductID,
+			ItemName:     item.ItemName,
+			ItemPrice:    item.ItemPrice,
+			ItemQuantity: item.ItemQuantity,
+			ItemImageUrl: item.ItemImageUrl,
+		}
+	}
+	return s.rebuild(template.Name, userID, storeID, at, lines)
+}
+
+func (s *service) Reorder(orderID, userID, storeID string, at *entities.LatLng) (*entities.Cart, []LineChange, error) {
+	order, err := s.repo.FindOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.UserID != userID {
+		return nil, nil, pkg.ErrForbidden
+	}
+	if storeID == "" {
+		storeID = order.StoreID
+	}
+	// The lines are read from the order, the cart may have been deleted
+	orderLines, err := s.repo.GetOrderLines(order.UUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := make([]entities.CartItem, len(*orderLines))
+	for i, line := range *orderLines {
+		lines[i] = entities.CartItem{
+			ProductID:    line.ProductID,
+			ItemName:     line.ItemName,
+			ItemPrice:    line.ItemPrice,
+			ItemQuantity: line.ItemQuantity,
+			ItemImageUrl: line.ItemImageUrl,
+		}
+	}
+	return s.rebuild(fmt.Sprintf("%s (reorder)", order.CartName), userID, storeID, at, lines)
+}
+
+// rebuild starts a new cart with the lines, re-pricing each one against the
+// store's current catalog. The cart is only stored once every line is
+// priced, together with its items, so a failure leaves nothing behind.
+func (s *service) rebuild(name, userID, storeID string, at *entities.LatLng, lines []entities.CartItem) (*entities.Cart, []LineChange, error) {
+	cart := &entities.Cart{
+		CartName: name,
+		UserID:   userID,
+		StoreID:  storeID,
+	}
+	if err := s.prepareCart(cart, at); err != nil {
+		return nil, nil, err
+	}
+
+	changes := make([]LineChange, 0, len(lines))
+	items := make([]entities.CartItem, 0, len(lines))
+	total := 0
+	for _, line := range lines {
+		change := LineChange{
+			ProductID: line.ProductID,
+			ItemName:  line.ItemName,
+			Quantity:  line.ItemQuantity,
+			OldPrice:  line.ItemPrice,
+			NewPrice:  line.ItemPrice,
+			Status:    LineUnchanged,
+		}
+
+		item := entities.CartItem{
+			ItemName:     line.ItemName,
+			ItemPrice:    line.ItemPrice,
+			ItemQuantity: line.ItemQuantity,
+			ItemImageUrl: line.ItemImageUrl,
+		}
+		if line.ProductID == "" || storeID == "" {
+			change.Status = LineNotInCatalog
+		} else {
+			product, err := s.products.GetStoreProduct(storeID, line.ProductID)
+			if errors.Is(err, pkg.ErrNotFound) {
+				change.Status = LineDiscontinued
+				changes = append(changes, change)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			item.ProductID = line.ProductID
+			item.ItemName = product.Name
+			item.ItemPrice = product.Price
+			if item.ItemImageUrl == "" {
+				item.ItemImageUrl = product.ImageUrl
+			}
+			change.NewPrice = product.Price
+			if product.Price != line.ItemPrice {
+				change.Status = LinePriceChanged
+			}
+		}
+
+		uuid, err := uuid2.NewV4()
+		if err != nil {
+			return nil, nil, err
+		}
+		item.UUID = uuid.String()
+		items = append(items, item)
+		total += item.ItemPrice * item.ItemQuantity
+		changes = append(changes, change)
+	}
+
+	cart, err := s.repo.CreateCart(cart, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The cart exists now, a failed budget alert must not fail the rebuild.
+	if _, err := s.budgetReport(cart, total); err != nil {
+		log.Printf("cart: budget report for %s failed: %s", cart.UUID, err)
+	}
+	return cart, changes, nil
+}
@@ -0,0 +1,106 @@
+package cart
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"log"
+	"time"
+)
+
+func (s *service) ArchiveCart(cartID, userID string, archived bool) (*entities.Cart, error) {
+	cart, err := s.ownCart(cartID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if archived == (cart.ArchivedAt != nil) {
+		return cart, nil
+	}
+	if archived {
+		now := time.Now()
+		cart.ArchivedAt = &now
+	} else {
+		cart.ArchivedAt = nil
+	}
+	return s.repo.SaveCart(cart)
+}
+
+func (s *service) DeleteCart(cartID, userID string) error {
+	cart, err := s.ownCart(cartID, userID)
+	if err != nil {
+		return err
+	}
+	if cart.CheckedOutAt != nil {
+		return pkg.ErrCheckedOut
+	}
+	return s.repo.SoftDeleteCart(cart)
+}
+
+func (s *service) GetTrash(userID string) (*[]entities.Cart, error) {
+	return s.repo.GetDeletedCarts(userID)
+}
+
+func (s *service) RestoreCart(cartID, userID string) (*entities.Cart, error) {
+	cart, err := s.repo.FindDeletedCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	if err := s.repo.RestoreCart(cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *service) PurgeCart(cartID, userID string) error {
+	cart, err := s.repo.FindDeletedCart(cartID)
+	if err != nil {
+		return err
+	}
+	if cart.UserID != userID {
+		return pkg.ErrForbidden
+	}
+	// Checked out carts trashed before DeleteCart refused them stay there
+	if cart.CheckedOutAt != nil {
+		return pkg.ErrCheckedOut
+	}
+	return s.repo.PurgeCart(cart.UUID)
+}
+
+func (s *service) PurgeTrash(retention time.Duration) (int, error) {
+	return s.repo.PurgeDeletedCarts(time.Now().Add(-retention))
+}
+
+// ownCart fetches a live cart and checks that it belongs to the user.
+func (s *service) ownCart(cartID, userID string) (*entities.Cart, error) {
+	cart, err := s.repo.FindCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	return cart, nil
+}
+
+// PurgeTrashEvery runs PurgeTrash on the given interval until stop is
+// closed. It is meant to be started in its own goroutine.
+func PurgeTrashEvery(svc Service, retention, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := svc.PurgeTrash(retention)
+		if err != nil {
+			log.Printf("cart: trash purge failed: %s", err)
+		} else if n > 0 {
+			log.Printf("cart: purged %d carts from the trash", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
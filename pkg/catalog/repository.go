@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type Repository interface {
+	CreateProduct(product *entities.Product) (*entities.Product, error)
+
+	SaveProduct(product *entities.Product) error
+
+	FindByUUID(uuid string) (*entities.Product, error)
+
+	FindByBarcode(barcode string) (*entities.Product, error)
+
+	FindStorePrice(storeID, productID string) (*entities.StorePrice, error)
+
+	SetStorePrice(storeID, productID string, price int) (*entities.StorePrice, error)
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) CreateProduct(product *entities.Product) (*entities.Product, error) {
+	result := r.DB.Create(product)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return product, nil
+}
+
+func (r *repo) SaveProduct(product *entities.Product) error {
+	err := r.DB.Save(product).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) FindByUUID(uuid string) (*entities.Product, error) {
+	product := &entities.Product{}
+	result := r.DB.Where("uuid = ?", uuid).First(product)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return product, nil
+}
+
+func (r *repo) FindByBarcode(barcode string) (*entities.Product, error) {
+	product := &entities.Product{}
+	result := r.DB.Where("barcode = ?", barcode).First(product)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return product, nil
+}
+
+func (r *repo) FindStorePrice(storeID, productID string) (*entities.StorePrice, error) {
+	price := &entities.StorePrice{}
+	result := r.DB.Where("store_id = ? AND product_id = ?", storeID, productID).First(price)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return price, nil
+}
+
+func (r *repo) SetStorePrice(storeID, productID string, price int) (*entities.StorePrice, error) {
+	sp := &entities.StorePrice{}
+	err := r.DB.Where(entities.StorePrice{StoreID: storeID, ProductID: productID}).
+		Assign(entities.StorePrice{Price: price}).
+		FirstOrCreate(sp).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return sp, nil
+}
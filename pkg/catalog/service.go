@@ -0,0 +1,104 @@
+package catalog
+
+import (
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strings"
+)
+
+// StoreProduct is a product as sold at one particular store.
+type StoreProduct struct {
+	entities.Product
+	StoreID string `json:"store_id"`
+	Price   int    `json:"price"`
+}
+
+type Service interface {
+	CreateProduct(product *entities.Product) (*entities.Product, error)
+
+	GetProduct(productID string) (*entities.Product, error)
+
+	GetProductByBarcode(barcode string) (*entities.Product, error)
+
+	// LookupBarcode finds a product by barcode together with its price at
+	// the store. Products not sold at the store are reported as not found.
+	LookupBarcode(storeID, barcode string) (*StoreProduct, error)
+
+	GetStoreProduct(storeID, productID string) (*StoreProduct, error)
+
+	SetPrice(storeID, productID string, price int) (*entities.StorePrice, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+func (s *service) CreateProduct(product *entities.Product) (*entities.Product, error) {
+	product.Barcode = strings.TrimSpace(product.Barcode)
+	if product.Barcode == "" || strings.TrimSpace(product.Name) == "" {
+		return nil, pkg.ErrNotAllowed
+	}
+	if _, err := s.repo.FindByBarcode(product.Barcode); err == nil {
+		return nil, pkg.ErrExists
+	}
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	product.UUID = uuid.String()
+
+	return s.repo.CreateProduct(product)
+}
+
+func (s *service) GetProduct(productID string) (*entities.Product, error) {
+	return s.repo.FindByUUID(productID)
+}
+
+func (s *service) GetProductByBarcode(barcode string) (*entities.Product, error) {
+	return s.repo.FindByBarcode(strings.TrimSpace(barcode))
+}
+
+func (s *service) LookupBarcode(storeID, barcode string) (*StoreProduct, error) {
+	product, err := s.repo.FindByBarcode(strings.TrimSpace(barcode))
+	if err != nil {
+		return nil, err
+	}
+	return s.withPrice(storeID, product)
+}
+
+func (s *service) GetStoreProduct(storeID, productID string) (*StoreProduct, error) {
+	product, err := s.repo.FindByUUID(productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPrice(storeID, product)
+}
+
+func (s *service) withPrice(storeID string, product *entities.Product) (*StoreProduct, error) {
+	price, err := s.repo.FindStorePrice(storeID, product.UUID)
+	if err != nil {
+		return nil, err
+	}
+	return &StoreProduct{
+		Product: *product,
+		StoreID: storeID,
+		Price:   price.Price,
+	}, nil
+}
+
+func (s *service) SetPrice(storeID, productID string, price int) (*entities.StorePrice, error) {
+	if price < 0 {
+		return nil, pkg.ErrNotAllowed
+	}
+	if _, err := s.repo.FindByUUID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.SetStorePrice(storeID, productID, price)
+}
@@ -0,0 +1,413 @@
+// Package config loads the settings of the application once at startup into
+// a typed Config, so a missing or malformed value stops the application
+// before it serves anything.
+//
+// Values are looked up in order of precedence: the environment, the .env
+// file, the YAML file and then the defaults. The YAML file is config.yaml
+// in the working directory unless CONFIG_FILE names another one. Both files
+// are optional.
+package config
+
+import (
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/nyaruka/phonenumbers"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFile = "config.yaml"
+	redacted    = "[redacted]"
+)
+
+// Where login attempts are counted, see user.AttemptStore.
+const (
+	AttemptStorePostgres = "postgres"
+	AttemptStoreMemory   = "memory"
+)
+
+// Ways of sending text messages and emails. SenderLog only writes them to
+// the log and is meant for local runs, it is refused on the server.
+const (
+	SenderLog    = "log"
+	SenderTwilio = "twilio"
+	SenderSMTP   = "smtp"
+)
+
+// MinSecretLength is the shortest JWT secret accepted, HS256 needs a key
+// of at least 256 bits to be as strong as the hash.
+const MinSecretLength = 32
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+type Config struct {
+	// OnServer is set when running on Heroku
+	OnServer bool `yaml:"on_server"`
+	// Port and GRPCPort are listened on on every interface. Without them
+	// the APIs are only served on localhost.
+	Port     string   `yaml:"port"`
+	GRPCPort string   `yaml:"grpc_port"`
+	Database Database `yaml:"database"`
+	JWT      JWT      `yaml:"jwt"`
+	SMS      SMS      `yaml:"sms"`
+	Email    Email    `yaml:"email"`
+	// LoginAttemptStore is AttemptStorePostgres or AttemptStoreMemory
+	LoginAttemptStore string `yaml:"login_attempt_store"`
+	// CartTrashRetentionDays is how long deleted carts stay in the trash
+	CartTrashRetentionDays int `yaml:"cart_trash_retention_days"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in
+	// front of the API, such as the Heroku router. X-Forwarded-For is only
+	// believed when it was set by one of them.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// Database is either a URL, as Heroku provides it, or its parts.
+type Database struct {
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Name     string `yaml:"name"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type JWT struct {
+	// Secret signs and verifies the tokens
+	Secret string `yaml:"secret"`
+	// TTL is how long a token is valid after it was issued
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// SMS sends the login codes, through Twilio or the log.
+type SMS struct {
+	// Provider is SenderTwilio or SenderLog
+	Provider   string `yaml:"provider"`
+	AccountSID string `yaml:"account_sid"`
+	AuthToken  string `yaml:"auth_token"`
+	// From is the number the messages are sent from
+	From string `yaml:"from"`
+	// DefaultRegion is the ISO 3166 region of phone numbers given without
+	// a country code
+	DefaultRegion string `yaml:"default_region"`
+}
+
+// Email sends the email change confirmations, through an SMTP server or the
+// log.
+type Email struct {
+	// Provider is SenderSMTP or SenderLog
+	Provider string `yaml:"provider"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From is the address the emails are sent from
+	From string `yaml:"from"`
+}
+
+func defaults() *Config {
+	return &Config{
+		Database: Database{
+			SSLMode: "require",
+		},
+		JWT:                    JWT{TTL: 7 * 24 * time.Hour},
+		SMS:                    SMS{Provider: SenderLog, DefaultRegion: "IN"},
+		Email:                  Email{Provider: SenderLog},
+		LoginAttemptStore:      AttemptStorePostgres,
+		CartTrashRetentionDays: 30,
+	}
+}
+
+// Load reads the configuration and validates it. The error lists every
+// problem found, not just the first.
+func Load() (*Config, error) {
+	cfg := defaults()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = defaultFile
+	}
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+	} else if explicit || !os.IsNotExist(err) {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	dotenv, err := godotenv.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("config: .env: %w", err)
+	}
+	lookup := func(name string) (string, bool) {
+		if v := os.Getenv(name); v != "" {
+			return v, true
+		}
+		v, ok := dotenv[name]
+		return v, ok
+	}
+
+	var problems []string
+	problems = append(problems, cfg.fromEnv(lookup)...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("config: %s", strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+// fromEnv overrides the values set in the environment, under the names
+// they have always had there. Empty values count as unset.
+func (c *Config) fromEnv(lookup func(string) (string, bool)) []string {
+	strs := map[string]*string{
+		"PORT":              &c.Port,
+		"GRPC_PORT":         &c.GRPCPort,
+		"DATABASE_URL":      &c.Database.URL,
+		"dbHost":            &c.Database.Host,
+		"dbPort":            &c.Database.Port,
+		"dbUser":            &c.Database.User,
+		"dbName":            &c.Database.Name,
+		"dbPass":            &c.Database.Password,
+		"sslmode":           &c.Database.SSLMode,
+		"jwt_secret":        &c.JWT.Secret,
+		"loginAttemptStore": &c.LoginAttemptStore,
+		"smsProvider":       &c.SMS.Provider,
+		"twilioAccountSid":  &c.SMS.AccountSID,
+		"twilioAuthToken":   &c.SMS.AuthToken,
+		"smsFrom":           &c.SMS.From,
+		"smsDefaultRegion":  &c.SMS.DefaultRegion,
+		"emailProvider":     &c.Email.Provider,
+		"smtpHost":          &c.Email.Host,
+		"smtpPort":          &c.Email.Port,
+		"smtpUser":          &c.Email.Username,
+		"smtpPass":          &c.Email.Password,
+		"emailFrom":         &c.Email.From,
+	}
+	for name, p := range strs {
+		if v, ok := lookup(name); ok && v != "" {
+			*p = v
+		}
+	}
+
+	var problems []string
+	if v, ok := lookup("trustedProxies"); ok && v != "" {
+		c.TrustedProxies = nil
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				c.TrustedProxies = append(c.TrustedProxies, p)
+			}
+		}
+	}
+	if v, ok := lookup("onServer"); ok && v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.OnServer = b
+		} else {
+			problems = append(problems, fmt.Sprintf("onServer must be true or false, got %q", v))
+		}
+	}
+	if v, ok := lookup("jwtTTL"); ok && v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.JWT.TTL = d
+		} else {
+			problems = append(problems, fmt.Sprintf("jwtTTL must be a duration such as 168h, got %q", v))
+		}
+	}
+	if v, ok := lookup("cartTrashRetentionDays"); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.CartTrashRetentionDays = n
+		} else {
+			problems = append(problems, fmt.Sprintf("cartTrashRetentionDays must be a number of days, got %q", v))
+		}
+	}
+	return problems
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	if c.JWT.Secret == "" {
+		problems = append(problems, "jwt_secret is required")
+	} else if len(c.JWT.Secret) < MinSecretLength {
+		problems = append(problems, fmt.Sprintf("jwt_secret must be at least %d characters", MinSecretLength))
+	}
+	if c.JWT.TTL < time.Minute {
+		problems = append(problems, "jwtTTL must be at least 1m")
+	}
+
+	if c.Port != "" && !validPort(c.Port) {
+		problems = append(problems, fmt.Sprintf("PORT must be a port number, got %q", c.Port))
+	}
+	if c.GRPCPort != "" && !validPort(c.GRPCPort) {
+		problems = append(problems, fmt.Sprintf("GRPC_PORT must be a port number, got %q", c.GRPCPort))
+	}
+
+	db := c.Database
+	if db.URL != "" {
+		u, err := url.Parse(db.URL)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Host == "" {
+			problems = append(problems, "DATABASE_URL must be a postgres:// URL")
+		}
+	} else {
+		required := []struct{ name, value string }{{"dbHost", db.Host}, {"dbUser", db.User}, {"dbName", db.Name}}
+		for _, r := range required {
+			if r.value == "" {
+				problems = append(problems, r.name+" is required unless DATABASE_URL is set")
+			}
+		}
+		if db.Port != "" && !validPort(db.Port) {
+			problems = append(problems, fmt.Sprintf("dbPort must be a port number, got %q", db.Port))
+		}
+		if !contains(sslModes, db.SSLMode) {
+			problems = append(problems, fmt.Sprintf("sslmode must be one of %s, got %q", strings.Join(sslModes, ", "), db.SSLMode))
+		}
+	}
+
+	problems = append(problems, c.validateSenders()...)
+
+	if c.LoginAttemptStore != AttemptStorePostgres && c.LoginAttemptStore != AttemptStoreMemory {
+		problems = append(problems, fmt.Sprintf("loginAttemptStore must be %s or %s, got %q", AttemptStorePostgres, AttemptStoreMemory, c.LoginAttemptStore))
+	}
+	if c.CartTrashRetentionDays < 1 {
+		problems = append(problems, "cartTrashRetentionDays must be at least 1")
+	}
+
+	for _, p := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			problems = append(problems, fmt.Sprintf("trustedProxies must be IP addresses or CIDR ranges, got %q", p))
+		}
+	}
+	if c.OnServer && len(c.TrustedProxies) == 0 {
+		problems = append(problems, "trustedProxies is required on the server, every client would share the address of the router otherwise")
+	}
+	return problems
+}
+
+func (c *Config) validateSenders() []string {
+	var problems []string
+	switch c.SMS.Provider {
+	case SenderLog:
+		if c.OnServer {
+			problems = append(problems, "smsProvider log writes login codes to the logs, it is only for local runs")
+		}
+	case SenderTwilio:
+		required := []struct{ name, value string }{{"twilioAccountSid", c.SMS.AccountSID}, {"twilioAuthToken", c.SMS.AuthToken}, {"smsFrom", c.SMS.From}}
+		for _, r := range required {
+			if r.value == "" {
+				problems = append(problems, r.name+" is required for smsProvider twilio")
+			}
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("smsProvider must be %s or %s, got %q", SenderTwilio, SenderLog, c.SMS.Provider))
+	}
+
+	if phonenumbers.GetCountryCodeForRegion(c.SMS.DefaultRegion) == 0 {
+		problems = append(problems, fmt.Sprintf("smsDefaultRegion must be an ISO 3166 region such as IN, got %q", c.SMS.DefaultRegion))
+	}
+
+	switch c.Email.Provider {
+	case SenderLog:
+		if c.OnServer {
+			problems = append(problems, "emailProvider log writes confirmation codes to the logs, it is only for local runs")
+		}
+	case SenderSMTP:
+		if c.Email.Host == "" {
+			problems = append(problems, "smtpHost is required for emailProvider smtp")
+		}
+		if !validPort(c.Email.Port) {
+			problems = append(problems, fmt.Sprintf("smtpPort must be a port number, got %q", c.Email.Port))
+		}
+		if !strings.Contains(c.Email.From, "@") {
+			problems = append(problems, fmt.Sprintf("emailFrom must be an email address, got %q", c.Email.From))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("emailProvider must be %s or %s, got %q", SenderSMTP, SenderLog, c.Email.Provider))
+	}
+	return problems
+}
+
+// HTTPAddr is the address the HTTP API listens on.
+func (c *Config) HTTPAddr() string {
+	return listenAddr(c.Port, "localhost:4000")
+}
+
+// GRPCAddr is the address the gRPC API listens on.
+func (c *Config) GRPCAddr() string {
+	return listenAddr(c.GRPCPort, "localhost:4001")
+}
+
+func listenAddr(port, fallback string) string {
+	if port == "" {
+		return fallback
+	}
+	return ":" + port
+}
+
+// DSN is the connection string of the database, for gorm.Open.
+func (d Database) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	dsn := fmt.Sprintf("host=%s user=%s dbname=%s password=%s sslmode=%s", d.Host, d.User, d.Name, quote(d.Password), d.SSLMode)
+	if d.Port != "" {
+		dsn += " port=" + d.Port
+	}
+	return dsn
+}
+
+// quote escapes a value of a key=value connection string, so a password
+// with spaces or quotes still connects.
+func quote(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+	return "'" + v + "'"
+}
+
+// String renders the configuration with its secrets redacted, so it can be
+// logged.
+func (c Config) String() string {
+	if c.JWT.Secret != "" {
+		c.JWT.Secret = redacted
+	}
+	if c.Database.Password != "" {
+		c.Database.Password = redacted
+	}
+	if c.SMS.AuthToken != "" {
+		c.SMS.AuthToken = redacted
+	}
+	if c.Email.Password != "" {
+		c.Email.Password = redacted
+	}
+	if u, err := url.Parse(c.Database.URL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+		}
+		c.Database.URL = u.String()
+	} else if err != nil {
+		c.Database.URL = redacted
+	}
+
+	// plain drops String, yaml would not call it but fmt would
+	type plain Config
+	out, _ := yaml.Marshal(plain(c))
+	return strings.TrimSpace(string(out))
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "github.com/jinzhu/gorm"
+
+type AuditEntry struct {
+	gorm.Model
+	Action string `json:"action"`
+	UserID string `json:"user_id"`
+	IP     string `json:"ip"`
+	Detail string `json:"detail"`
+}
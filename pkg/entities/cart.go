@@ -1,20 +1,70 @@
 package entities
 
-import "github.com/jinzhu/gorm"
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
 
 type Cart struct {
 	gorm.Model
-	UUID     string `json:"id"`
-	CartName string `json:"cart_name"`
-	UserID   string `json:"user_id"`
+	UUID         string     `json:"id"`
+	CartName     string     `json:"cart_name"`
+	UserID       string     `json:"user_id"`
+	StoreID      string     `json:"store_id"`
+	CheckedOutAt *time.Time `json:"checked_out_at"`
+	ArchivedAt   *time.Time `json:"archived_at"`
+	Budget       int        `json:"budget"`
 }
 
 type CartItem struct {
 	gorm.Model
 	UUID         string `json:"id"`
 	CartID       string `json:"cart_id"`
+	ProductID    string `json:"product_id"`
 	ItemName     string `json:"item_name"`
 	ItemPrice    int    `json:"item_price"`
 	ItemQuantity int    `json:"item_quantity"`
 	ItemImageUrl string `json:"item_image_url"`
 }
+
+// CartTemplate is a saved set of items, such as a weekly shop, that new
+// carts can be started from.
+type CartTemplate struct {
+	gorm.Model
+	UUID    string `json:"id"`
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	StoreID string `json:"store_id"`
+}
+
+type CartTemplateItem struct {
+	gorm.Model
+	TemplateID   string `json:"template_id"`
+	ProductID    string `json:"product_id"`
+	ItemName     string `json:"item_name"`
+	ItemPrice    int    `json:"item_price"`
+	ItemQuantity int    `json:"item_quantity"`
+	ItemImageUrl string `json:"item_image_url"`
+}
+
+// MonthlyBudget is the amount a user plans to spend on groceries in a
+// calendar month.
+type MonthlyBudget struct {
+	gorm.Model
+	UserID string `json:"user_id" gorm:"unique_index"`
+	Amount int    `json:"amount"`
+	// Timezone is where the user lives, their months start at midnight
+	// there.
+	Timezone string `json:"timezone"`
+}
+
+// BudgetAlert records that the user was notified about a budget threshold.
+// Period is the cart for cart budgets and the month, as 2006-01, for the
+// monthly budget, so each threshold is notified once per cart and month.
+type BudgetAlert struct {
+	gorm.Model
+	UserID    string `json:"user_id"`
+	Scope     string `json:"scope"`
+	Period    string `json:"period"`
+	Threshold int    `json:"threshold"`
+}
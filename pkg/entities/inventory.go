@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Stock indicators shown to shoppers.
+const (
+	StockUnknown = "unknown"
+	StockIn      = "in_stock"
+	StockLow     = "low_stock"
+	StockOut     = "out_of_stock"
+)
+
+// StockLevel is the stock on hand of a product at one store.
+type StockLevel struct {
+	gorm.Model
+	StoreID           string     `json:"store_id"`
+	ProductID         string     `json:"product_id"`
+	Quantity          int        `json:"quantity"`
+	LowStockThreshold int        `json:"low_stock_threshold"`
+	LowStockAlertedAt *time.Time `json:"low_stock_alerted_at"`
+}
+
+// Status classifies the stock level for display.
+func (s *StockLevel) Status() string {
+	switch {
+	case s == nil:
+		return StockUnknown
+	case s.Quantity <= 0:
+		return StockOut
+	case s.Quantity <= s.LowStockThreshold:
+		return StockLow
+	}
+	return StockIn
+}
@@ -0,0 +1,41 @@
+package entities
+
+import "github.com/jinzhu/gorm"
+
+// A store layout is a tree of zones, aisles and bays. Sequence numbers give
+// the order in which shoppers walk past them, lowest first.
+type StoreZone struct {
+	gorm.Model
+	UUID     string `json:"id"`
+	StoreID  string `json:"store_id"`
+	Name     string `json:"name"`
+	Sequence int    `json:"sequence"`
+}
+
+type Aisle struct {
+	gorm.Model
+	UUID     string `json:"id"`
+	StoreID  string `json:"store_id"`
+	ZoneID   string `json:"zone_id"`
+	Name     string `json:"name"`
+	Sequence int    `json:"sequence"`
+}
+
+type Bay struct {
+	gorm.Model
+	UUID     string `json:"id"`
+	StoreID  string `json:"store_id"`
+	AisleID  string `json:"aisle_id"`
+	Name     string `json:"name"`
+	Sequence int    `json:"sequence"`
+}
+
+// ProductPlacement puts a product on a shelf of a bay. A product has at
+// most one placement per store.
+type ProductPlacement struct {
+	gorm.Model
+	StoreID   string `json:"store_id"`
+	ProductID string `json:"product_id"`
+	BayID     string `json:"bay_id"`
+	Shelf     int    `json:"shelf"`
+}
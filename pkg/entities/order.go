@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const OrderStatusCompleted = "completed"
+
+// Order is created when a cart is checked out. Its lines are copied from
+// the cart's items, so the order outlives the cart.
+type Order struct {
+	gorm.Model
+	UUID        string    `json:"id"`
+	CartID      string    `json:"cart_id"`
+	CartName    string    `json:"cart_name"`
+	UserID      string    `json:"user_id"`
+	StoreID     string    `json:"store_id"`
+	Total       int       `json:"total"`
+	Currency    string    `json:"currency"`
+	ItemCount   int       `json:"item_count"`
+	Status      string    `json:"status"`
+	CompletedAt time.Time `json:"completed_at"`
+	// StockShortfall flags an order that took more stock than the store
+	// had recorded, for the stock team to reconcile
+	StockShortfall bool `json:"stock_shortfall"`
+}
+
+// OrderLine is an item of a cart as it was checked out.
+type OrderLine struct {
+	gorm.Model
+	OrderID      string `json:"order_id"`
+	ProductID    string `json:"product_id"`
+	ItemName     string `json:"item_name"`
+	ItemPrice    int    `json:"item_price"`
+	ItemQuantity int    `json:"item_quantity"`
+	ItemImageUrl string `json:"item_image_url"`
+	// StockShortfall is the quantity the recorded stock did not cover
+	StockShortfall int `json:"stock_shortfall"`
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "github.com/jinzhu/gorm"
+
+type Product struct {
+	gorm.Model
+	UUID     string `json:"id"`
+	Barcode  string `json:"barcode"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	ImageUrl string `json:"image_url"`
+}
+
+// StorePrice is the price of a product at one store, in the smallest unit
+// of the store's currency. A product without a price is not sold there.
+type StorePrice struct {
+	gorm.Model
+	StoreID   string `json:"store_id"`
+	ProductID string `json:"product_id"`
+	Price     int    `json:"price"`
+}
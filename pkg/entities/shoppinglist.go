@@ -0,0 +1,25 @@
+package entities
+
+import "github.com/jinzhu/gorm"
+
+// ShoppingList is planned before going to the store. A user has at most
+// one active list, which scanned cart items are matched against.
+type ShoppingList struct {
+	gorm.Model
+	UUID   string `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// ShoppingListEntry is either free text ("milk") or linked to a catalog
+// product.
+type ShoppingListEntry struct {
+	gorm.Model
+	UUID      string `json:"id"`
+	ListID    string `json:"list_id"`
+	ProductID string `json:"product_id"`
+	Text      string `json:"text"`
+	Quantity  int    `json:"quantity"`
+	Checked   bool   `json:"checked"`
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type Store struct {
+	gorm.Model
+	UUID      string  `json:"id"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	City      string  `json:"city"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+	Currency  string  `json:"currency"`
+	Geofence  Polygon `json:"geofence" gorm:"type:text"`
+}
+
+// StoreHours is one opening window on a weekday, in the store's timezone.
+// Times are "HH:MM" and Closes may be "24:00" for midnight. A window
+// closing before it opens runs past midnight into the next day.
+type StoreHours struct {
+	gorm.Model
+	StoreID string       `json:"store_id"`
+	Weekday time.Weekday `json:"weekday"`
+	Opens   string       `json:"opens"`
+	Closes  string       `json:"closes"`
+}
+
+// StoreHoliday overrides the weekly hours on a single date, either closing
+// the store for the day or replacing its hours.
+type StoreHoliday struct {
+	gorm.Model
+	StoreID string `json:"store_id"`
+	Date    string `json:"date"`
+	Name    string `json:"name"`
+	Closed  bool   `json:"closed"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
@@ -1,13 +1,88 @@
 package entities
 
-import "github.com/jinzhu/gorm"
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Roles carried in the JWT.
+const (
+	RoleUser  = "user"
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
 
 type User struct {
 	gorm.Model
 	UUID        string `json:"id"`
 	Name        string `json:"name"`
 	Email       string `json:"email"`
-	Password    string `json:"password"`
+	Password    string `json:"-"`
 	ImageUrl    string `json:"image_url"`
 	PhoneNumber string `json:"phone_number"`
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"totp_enabled"`
+	// TOTPLastStep is the time step of the last TOTP code accepted, codes
+	// of that step or earlier are refused.
+	TOTPLastStep int64  `json:"-"`
+	Role         string `json:"role"`
+	// StoreID is the store a staff member works at, staff only manage the
+	// stock of their own store.
+	StoreID string `json:"store_id,omitempty"`
+	// DisabledAt is set while the account is blocked from logging in.
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+// PhoneOTP is a one time code sent over SMS for passwordless login.
+// Only the bcrypt hash of the code is stored.
+type PhoneOTP struct {
+	gorm.Model
+	PhoneNumber string    `json:"phone_number"`
+	CodeHash    string    `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Attempts    int       `json:"attempts"`
+	Consumed    bool      `json:"consumed"`
+}
+
+// RecoveryCode is a single use code that can replace a TOTP code when the
+// user has lost access to their authenticator.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   string `json:"user_id"`
+	CodeHash string `json:"-"`
+	Used     bool   `json:"used"`
+}
+
+// LoginChallenge is issued after the first login factor succeeded for an
+// account with two factor authentication, and is exchanged for a JWT once
+// a valid TOTP or recovery code is supplied.
+type LoginChallenge struct {
+	gorm.Model
+	UserID    string    `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts"`
+	Consumed  bool      `json:"consumed"`
+}
+
+// LoginAttempt tracks failed logins for a single key, which is either an
+// account (by email), a client IP or the second factor of a user (by
+// UUID).
+type LoginAttempt struct {
+	gorm.Model
+	Key           string    `json:"key" gorm:"unique_index"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+// EmailChange is a pending change of email address that takes effect once
+// the link sent to the new address is followed.
+type EmailChange struct {
+	gorm.Model
+	UserID    string    `json:"user_id"`
+	NewEmail  string    `json:"new_email"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Consumed  bool      `json:"consumed"`
 }
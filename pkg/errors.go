@@ -1,17 +1,106 @@
 package pkg
 
-import "errors"
+import (
+	"fmt"
+	"time"
+)
 
 //noinspection ALL
 var (
-	ErrNotFound     = errors.New("Error: Document not found")
-	ErrNoContent    = errors.New("Error: Document not found")
-	ErrInvalidSlug  = errors.New("Error: Invalid slug")
-	ErrExists       = errors.New("Error: Document already exists")
-	ErrDatabase     = errors.New("Error: Database error")
-	ErrUnauthorized = errors.New("Error: You are not allowed to perform this action")
-	ErrForbidden    = errors.New("Error: Access to this resource is forbidden")
-	ErrEmail        = errors.New("Error: Email not valid")
-	ErrPassword     = errors.New("Error: Password must be greater than 6 chars")
-	ErrNotAllowed   = errors.New("Error: Not allowed")
+	ErrNotFound        = NewError("not_found", "Error: Document not found")
+	ErrNoContent       = NewError("no_content", "Error: Document has no content")
+	ErrInvalidSlug     = NewError("invalid_slug", "Error: Invalid slug")
+	ErrExists          = NewError("already_exists", "Error: Document already exists")
+	ErrDatabase        = NewError("database_error", "Error: Database error")
+	ErrUnauthorized    = NewError("unauthorized", "Error: You are not allowed to perform this action")
+	ErrForbidden       = NewError("forbidden", "Error: Access to this resource is forbidden")
+	ErrEmail           = NewError("invalid_email", "Error: Email not valid")
+	ErrPassword        = NewError("invalid_password", "Error: Password must be greater than 6 chars")
+	ErrNotAllowed      = NewError("not_allowed", "Error: Not allowed")
+	ErrPhoneNumber     = NewError("invalid_phone_number", "Error: Phone number not valid")
+	ErrInvalidCode     = NewError("invalid_code", "Error: Invalid or expired code")
+	ErrTooManyRequests = NewError("too_many_requests", "Error: Too many attempts, try again later")
+	ErrTOTPEnabled     = NewError("totp_enabled", "Error: Two factor authentication is already enabled")
+	ErrTOTPNotEnrolled = NewError("totp_not_enrolled", "Error: Two factor authentication is not set up")
+	ErrLocked          = NewError("account_locked", "Error: Too many failed logins, account temporarily locked")
+	ErrName            = NewError("invalid_name", "Error: Name must be between 1 and 100 chars")
+	ErrImageUrl        = NewError("invalid_image_url", "Error: Image URL not valid")
+	ErrWrongPassword   = NewError("wrong_password", "Error: Current password is incorrect")
+	ErrTimezone        = NewError("invalid_timezone", "Error: Timezone not valid")
+	ErrCurrency        = NewError("invalid_currency", "Error: Currency must be a 3 letter ISO 4217 code")
+	ErrCoordinates     = NewError("invalid_coordinates", "Error: Coordinates not valid")
+	ErrGeofence        = NewError("invalid_geofence", "Error: Geofence needs at least 3 valid points")
+	ErrNoLocation      = NewError("location_required", "Error: Location is required to start a cart at a store")
+	ErrOutsideStore    = NewError("outside_store", "Error: You must be inside the store to start a cart")
+	ErrOpeningHours    = NewError("invalid_opening_hours", "Error: Opening hours not valid")
+	ErrDate            = NewError("invalid_date", "Error: Date must be in YYYY-MM-DD format")
+	ErrStoreClosed     = NewError("store_closed", "Error: Store is closed, checkout is not possible right now")
+	ErrCheckedOut      = NewError("cart_checked_out", "Error: Cart has already been checked out")
+	ErrEmptyCart       = NewError("cart_empty", "Error: Cart is empty")
+	ErrQuantity        = NewError("invalid_quantity", "Error: Quantity not valid")
+	ErrBudget          = NewError("invalid_budget", "Error: Budget cannot be negative")
+	ErrDateRange       = NewError("invalid_date_range", "Error: Start date must not be after end date")
+	ErrLimit           = NewError("invalid_limit", "Error: Limit must be between 1 and 100")
+	ErrValidation      = NewError("validation_failed", "Error: Request is not valid")
+	ErrBody            = NewError("invalid_body", "Error: Request body is not valid JSON")
+	ErrBodyTooLarge    = NewError("body_too_large", "Error: Request body is too large")
+	ErrSort            = NewError("invalid_sort", "Error: Sort must be one of created, updated, name or price")
+	ErrOrder           = NewError("invalid_order", "Error: Order must be asc or desc")
+	ErrCursor          = NewError("invalid_cursor", "Error: Cursor not valid for this listing")
+	ErrArchived        = NewError("invalid_archived", "Error: Archived must be true or false")
+	ErrDisabled        = NewError("account_disabled", "Error: This account has been disabled")
+	ErrStaleToken      = NewError("stale_token", "Error: Token is no longer valid, log in again")
 )
+
+// Error is a domain error with a stable machine readable code. Clients
+// should match on the code, the message is meant for people and may change.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func NewError(code, message string) error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every invalid field of a request at once. It
+// matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrValidation.Error()
+	}
+	return fmt.Sprintf("%s: %s %s", ErrValidation.Error(), e.Fields[0].Field, e.Fields[0].Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// RetryError tells the client how long to wait before trying again.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
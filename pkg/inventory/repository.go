@@ -0,0 +1,107 @@
+package inventory
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"time"
+)
+
+type Repository interface {
+	FindStock(storeID, productID string) (*entities.StockLevel, error)
+
+	SaveStock(stock *entities.StockLevel) error
+
+	// AdjustStock atomically adds delta to the quantity on hand, creating
+	// the stock level when missing. Quantities never drop below zero.
+	AdjustStock(storeID, productID string, delta int) (*entities.StockLevel, error)
+
+	GetLowStock(storeID string) (*[]entities.StockLevel, error)
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) FindStock(storeID, productID string) (*entities.StockLevel, error) {
+	stock := &entities.StockLevel{}
+	result := r.DB.Where("store_id = ? AND product_id = ?", storeID, productID).First(stock)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return stock, nil
+}
+
+func (r *repo) SaveStock(stock *entities.StockLevel) error {
+	err := r.DB.Save(stock).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) AdjustStock(storeID, productID string, delta int) (*entities.StockLevel, error) {
+	tx := r.DB.Begin()
+	stock, _, err := Adjust(tx, storeID, productID, delta)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return stock, nil
+}
+
+// Adjust adds delta to the quantity on hand within the transaction tx,
+// creating the stock level when missing. The quantity never drops below
+// zero, the units it could not cover are returned as the shortfall. It lets
+// other packages take stock in their own transactions.
+func Adjust(tx *gorm.DB, storeID, productID string, delta int) (*entities.StockLevel, int, error) {
+	// Concurrent first adjustments of a product insert a single row, the
+	// unique index makes every other one a no-op.
+	now := time.Now()
+	err := tx.Exec(`INSERT INTO stock_levels (created_at, updated_at, store_id, product_id, quantity, low_stock_threshold)
+VALUES (?, ?, ?, ?, 0, 0)
+ON CONFLICT (store_id, product_id) DO NOTHING`, now, now, storeID, productID).Error
+	if err != nil {
+		return nil, 0, pkg.ErrDatabase
+	}
+	stock := &entities.StockLevel{}
+	err = tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("store_id = ? AND product_id = ?", storeID, productID).First(stock).Error
+	if err != nil {
+		return nil, 0, pkg.ErrDatabase
+	}
+
+	shortfall := 0
+	stock.Quantity += delta
+	if stock.Quantity < 0 {
+		shortfall = -stock.Quantity
+		stock.Quantity = 0
+	}
+	if err := tx.Save(stock).Error; err != nil {
+		return nil, 0, pkg.ErrDatabase
+	}
+	return stock, shortfall, nil
+}
+
+func (r *repo) GetLowStock(storeID string) (*[]entities.StockLevel, error) {
+	var stock []entities.StockLevel
+	err := r.DB.Where("store_id = ? AND quantity <= low_stock_threshold", storeID).
+		Order("quantity").Find(&stock).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &stock, nil
+}
@@ -0,0 +1,114 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/notify"
+	"log"
+	"time"
+)
+
+type Service interface {
+	// GetStock returns the stock level, or nil without an error when the
+	// product is not tracked at the store.
+	GetStock(storeID, productID string) (*entities.StockLevel, error)
+
+	Restock(storeID, productID string, quantity int) (*entities.StockLevel, error)
+
+	SetThreshold(storeID, productID string, threshold int) (*entities.StockLevel, error)
+
+	GetLowStock(storeID string) (*[]entities.StockLevel, error)
+
+	// StockTaken alerts on the stock levels an order has taken stock from.
+	// Orders take their stock in the checkout transaction, see Adjust.
+	StockTaken(levels []entities.StockLevel)
+}
+
+type service struct {
+	repo     Repository
+	notifier notify.Notifier
+}
+
+func NewService(r Repository, notifier notify.Notifier) Service {
+	return &service{
+		repo:     r,
+		notifier: notifier,
+	}
+}
+
+func (s *service) GetStock(storeID, productID string) (*entities.StockLevel, error) {
+	stock, err := s.repo.FindStock(storeID, productID)
+	if errors.Is(err, pkg.ErrNotFound) {
+		return nil, nil
+	}
+	return stock, err
+}
+
+func (s *service) Restock(storeID, productID string, quantity int) (*entities.StockLevel, error) {
+	if quantity <= 0 {
+		return nil, pkg.ErrQuantity
+	}
+	stock, err := s.repo.AdjustStock(storeID, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	// Back above the threshold, so the next dip should alert again.
+	if stock.LowStockAlertedAt != nil && stock.Quantity > stock.LowStockThreshold {
+		stock.LowStockAlertedAt = nil
+		if err := s.repo.SaveStock(stock); err != nil {
+			return nil, err
+		}
+	}
+	return stock, nil
+}
+
+func (s *service) SetThreshold(storeID, productID string, threshold int) (*entities.StockLevel, error) {
+	if threshold < 0 {
+		return nil, pkg.ErrQuantity
+	}
+	// Adjusting by nothing creates the stock level when it is missing.
+	stock, err := s.repo.AdjustStock(storeID, productID, 0)
+	if err != nil {
+		return nil, err
+	}
+	stock.LowStockThreshold = threshold
+	if err := s.repo.SaveStock(stock); err != nil {
+		return nil, err
+	}
+	s.checkLowStock(stock)
+	return stock, nil
+}
+
+func (s *service) GetLowStock(storeID string) (*[]entities.StockLevel, error) {
+	return s.repo.GetLowStock(storeID)
+}
+
+func (s *service) StockTaken(levels []entities.StockLevel) {
+	for i := range levels {
+		s.checkLowStock(&levels[i])
+	}
+}
+
+// checkLowStock alerts the store's staff once when a product drops to its
+// low stock threshold.
+func (s *service) checkLowStock(stock *entities.StockLevel) {
+	if stock.LowStockAlertedAt != nil || stock.Quantity > stock.LowStockThreshold {
+		return
+	}
+	err := s.notifier.Notify(
+		"store:"+stock.StoreID,
+		"Low stock",
+		fmt.Sprintf("Product %s is down to %d", stock.ProductID, stock.Quantity),
+	)
+	if err != nil {
+		log.Printf("inventory: low stock alert failed: %s", err)
+		return
+	}
+	now := time.Now()
+	stock.LowStockAlertedAt = &now
+	if err := s.repo.SaveStock(stock); err != nil {
+		log.Printf("inventory: could not mark low stock alert: %s", err)
+	}
+}
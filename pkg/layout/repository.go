@@ -0,0 +1,132 @@
+package layout
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type Repository interface {
+	CreateZone(zone *entities.StoreZone) (*entities.StoreZone, error)
+
+	FindZone(zoneID string) (*entities.StoreZone, error)
+
+	CreateAisle(aisle *entities.Aisle) (*entities.Aisle, error)
+
+	FindAisle(aisleID string) (*entities.Aisle, error)
+
+	CreateBay(bay *entities.Bay) (*entities.Bay, error)
+
+	FindBay(bayID string) (*entities.Bay, error)
+
+	// SetPlacement moves the product to the given bay, replacing any
+	// previous placement at the same store.
+	SetPlacement(placement *entities.ProductPlacement) (*entities.ProductPlacement, error)
+
+	FindLocations(storeID string, productIDs []string) (*[]Location, error)
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) CreateZone(zone *entities.StoreZone) (*entities.StoreZone, error) {
+	result := r.DB.Create(zone)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return zone, nil
+}
+
+func (r *repo) FindZone(zoneID string) (*entities.StoreZone, error) {
+	zone := &entities.StoreZone{}
+	result := r.DB.Where("uuid = ?", zoneID).First(zone)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return zone, nil
+}
+
+func (r *repo) CreateAisle(aisle *entities.Aisle) (*entities.Aisle, error) {
+	result := r.DB.Create(aisle)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return aisle, nil
+}
+
+func (r *repo) FindAisle(aisleID string) (*entities.Aisle, error) {
+	aisle := &entities.Aisle{}
+	result := r.DB.Where("uuid = ?", aisleID).First(aisle)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return aisle, nil
+}
+
+func (r *repo) CreateBay(bay *entities.Bay) (*entities.Bay, error) {
+	result := r.DB.Create(bay)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return bay, nil
+}
+
+func (r *repo) FindBay(bayID string) (*entities.Bay, error) {
+	bay := &entities.Bay{}
+	result := r.DB.Where("uuid = ?", bayID).First(bay)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return bay, nil
+}
+
+func (r *repo) SetPlacement(placement *entities.ProductPlacement) (*entities.ProductPlacement, error) {
+	p := &entities.ProductPlacement{}
+	err := r.DB.Where(entities.ProductPlacement{StoreID: placement.StoreID, ProductID: placement.ProductID}).
+		Assign(entities.ProductPlacement{BayID: placement.BayID, Shelf: placement.Shelf}).
+		FirstOrCreate(p).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return p, nil
+}
+
+func (r *repo) FindLocations(storeID string, productIDs []string) (*[]Location, error) {
+	var locations []Location
+	if len(productIDs) == 0 {
+		return &locations, nil
+	}
+	err := r.DB.Table("product_placements AS pp").
+		Select(`pp.product_id, pp.shelf,
+			z.name AS zone, z.sequence AS zone_sequence,
+			a.name AS aisle, a.sequence AS aisle_sequence,
+			b.name AS bay, b.sequence AS bay_sequence`).
+		Joins("JOIN bays AS b ON b.uuid = pp.bay_id AND b.deleted_at IS NULL").
+		Joins("JOIN aisles AS a ON a.uuid = b.aisle_id AND a.deleted_at IS NULL").
+		Joins("JOIN store_zones AS z ON z.uuid = a.zone_id AND z.deleted_at IS NULL").
+		Where("pp.store_id = ? AND pp.product_id IN (?) AND pp.deleted_at IS NULL", storeID, productIDs).
+		Scan(&locations).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &locations, nil
+}
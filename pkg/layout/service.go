@@ -0,0 +1,176 @@
+package layout
+
+import (
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"sort"
+	"strings"
+)
+
+// Location is where a product sits in a store.
+type Location struct {
+	ProductID     string `json:"product_id"`
+	Zone          string `json:"zone"`
+	Aisle         string `json:"aisle"`
+	Bay           string `json:"bay"`
+	Shelf         int    `json:"shelf"`
+	ZoneSequence  int    `json:"-"`
+	AisleSequence int    `json:"-"`
+	BaySequence   int    `json:"-"`
+}
+
+// Stop is one entry of a walking route. Index points back into the slice
+// of product IDs the route was computed for, and Location is nil for
+// products that have no placement in the store.
+type Stop struct {
+	Index     int       `json:"index"`
+	ProductID string    `json:"product_id"`
+	Location  *Location `json:"location"`
+}
+
+type Service interface {
+	CreateZone(zone *entities.StoreZone) (*entities.StoreZone, error)
+
+	CreateAisle(aisle *entities.Aisle) (*entities.Aisle, error)
+
+	CreateBay(bay *entities.Bay) (*entities.Bay, error)
+
+	PlaceProduct(placement *entities.ProductPlacement) (*entities.ProductPlacement, error)
+
+	Locate(storeID, productID string) (*Location, error)
+
+	// Route sorts products into walking order for the store. Products that
+	// are not placed come last, in their original order.
+	Route(storeID string, productIDs []string) ([]Stop, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+func newUUID() (string, error) {
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return uuid.String(), nil
+}
+
+func (s *service) CreateZone(zone *entities.StoreZone) (*entities.StoreZone, error) {
+	if strings.TrimSpace(zone.Name) == "" || zone.StoreID == "" {
+		return nil, pkg.ErrNotAllowed
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	zone.UUID = uuid
+	return s.repo.CreateZone(zone)
+}
+
+func (s *service) CreateAisle(aisle *entities.Aisle) (*entities.Aisle, error) {
+	if strings.TrimSpace(aisle.Name) == "" {
+		return nil, pkg.ErrNotAllowed
+	}
+	zone, err := s.repo.FindZone(aisle.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	aisle.UUID = uuid
+	aisle.StoreID = zone.StoreID
+	return s.repo.CreateAisle(aisle)
+}
+
+func (s *service) CreateBay(bay *entities.Bay) (*entities.Bay, error) {
+	if strings.TrimSpace(bay.Name) == "" {
+		return nil, pkg.ErrNotAllowed
+	}
+	aisle, err := s.repo.FindAisle(bay.AisleID)
+	if err != nil {
+		return nil, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	bay.UUID = uuid
+	bay.StoreID = aisle.StoreID
+	return s.repo.CreateBay(bay)
+}
+
+func (s *service) PlaceProduct(placement *entities.ProductPlacement) (*entities.ProductPlacement, error) {
+	if placement.ProductID == "" || placement.Shelf < 0 {
+		return nil, pkg.ErrNotAllowed
+	}
+	bay, err := s.repo.FindBay(placement.BayID)
+	if err != nil {
+		return nil, err
+	}
+	placement.StoreID = bay.StoreID
+	return s.repo.SetPlacement(placement)
+}
+
+func (s *service) Locate(storeID, productID string) (*Location, error) {
+	locations, err := s.repo.FindLocations(storeID, []string{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(*locations) == 0 {
+		return nil, pkg.ErrNotFound
+	}
+	return &(*locations)[0], nil
+}
+
+func (s *service) Route(storeID string, productIDs []string) ([]Stop, error) {
+	locations, err := s.repo.FindLocations(storeID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[string]*Location, len(*locations))
+	for i := range *locations {
+		l := &(*locations)[i]
+		byProduct[l.ProductID] = l
+	}
+
+	stops := make([]Stop, len(productIDs))
+	for i, id := range productIDs {
+		stops[i] = Stop{Index: i, ProductID: id, Location: byProduct[id]}
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		return walksBefore(stops[i].Location, stops[j].Location)
+	})
+	return stops, nil
+}
+
+// walksBefore orders locations by zone, then aisle, then bay. Aisles are
+// walked in a snake pattern, so bays of every other aisle are visited in
+// reverse order instead of walking back to the start of the aisle.
+func walksBefore(a, b *Location) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	if a.ZoneSequence != b.ZoneSequence {
+		return a.ZoneSequence < b.ZoneSequence
+	}
+	if a.AisleSequence != b.AisleSequence {
+		return a.AisleSequence < b.AisleSequence
+	}
+	if a.BaySequence != b.BaySequence {
+		if a.AisleSequence%2 != 0 {
+			return a.BaySequence > b.BaySequence
+		}
+		return a.BaySequence < b.BaySequence
+	}
+	return a.Shelf < b.Shelf
+}
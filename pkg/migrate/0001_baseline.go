@@ -0,0 +1,405 @@
+package migrate
+
+// baseline creates the schema that AutoMigrate used to create, so fresh
+// databases start out the same as existing ones. Existing databases were
+// created by whichever release they last ran, so their tables may predate
+// columns added since. It only creates the tables, columns and indexes that
+// are missing.
+var baseline = Migration{
+	Version: 1,
+	Name:    "baseline",
+	Up: `
+CREATE TABLE IF NOT EXISTS users (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	name text,
+	email text,
+	password text,
+	image_url text,
+	phone_number text,
+	totp_secret text,
+	totp_enabled boolean,
+	role text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled boolean;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text;
+
+CREATE TABLE IF NOT EXISTS phone_otps (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	phone_number text,
+	code_hash text,
+	expires_at timestamp with time zone,
+	attempts integer,
+	consumed boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_phone_otps_deleted_at ON phone_otps (deleted_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	user_id text,
+	code_hash text,
+	used boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	user_id text,
+	token_hash text,
+	expires_at timestamp with time zone,
+	attempts integer,
+	consumed boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_deleted_at ON login_challenges (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	"key" text,
+	failures integer,
+	last_failure_at timestamp with time zone,
+	locked_until timestamp with time zone,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_deleted_at ON login_attempts (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS uix_login_attempts_key ON login_attempts ("key");
+
+CREATE TABLE IF NOT EXISTS email_changes (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	user_id text,
+	new_email text,
+	token_hash text,
+	expires_at timestamp with time zone,
+	consumed boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_email_changes_deleted_at ON email_changes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	action text,
+	user_id text,
+	ip text,
+	detail text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_deleted_at ON audit_entries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS carts (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	cart_name text,
+	user_id text,
+	store_id text,
+	checked_out_at timestamp with time zone,
+	archived_at timestamp with time zone,
+	budget integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_carts_deleted_at ON carts (deleted_at);
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS store_id text;
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS checked_out_at timestamp with time zone;
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS archived_at timestamp with time zone;
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS budget integer;
+
+CREATE TABLE IF NOT EXISTS cart_items (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	cart_id text,
+	product_id text,
+	item_name text,
+	item_price integer,
+	item_quantity integer,
+	item_image_url text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_cart_items_deleted_at ON cart_items (deleted_at);
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS product_id text;
+
+CREATE TABLE IF NOT EXISTS cart_templates (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	user_id text,
+	name text,
+	store_id text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_cart_templates_deleted_at ON cart_templates (deleted_at);
+
+CREATE TABLE IF NOT EXISTS cart_template_items (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	template_id text,
+	product_id text,
+	item_name text,
+	item_price integer,
+	item_quantity integer,
+	item_image_url text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_cart_template_items_deleted_at ON cart_template_items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS monthly_budgets (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	user_id text,
+	amount integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_monthly_budgets_deleted_at ON monthly_budgets (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS uix_monthly_budgets_user_id ON monthly_budgets (user_id);
+
+CREATE TABLE IF NOT EXISTS stores (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	name text,
+	address text,
+	city text,
+	latitude numeric,
+	longitude numeric,
+	timezone text,
+	currency text,
+	geofence text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stores_deleted_at ON stores (deleted_at);
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS geofence text;
+
+CREATE TABLE IF NOT EXISTS products (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	barcode text,
+	name text,
+	category text,
+	image_url text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS store_prices (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	store_id text,
+	product_id text,
+	price integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_store_prices_deleted_at ON store_prices (deleted_at);
+
+CREATE TABLE IF NOT EXISTS store_hours (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	store_id text,
+	weekday integer,
+	opens text,
+	closes text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_store_hours_deleted_at ON store_hours (deleted_at);
+
+CREATE TABLE IF NOT EXISTS store_holidays (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	store_id text,
+	"date" text,
+	name text,
+	closed boolean,
+	opens text,
+	closes text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_store_holidays_deleted_at ON store_holidays (deleted_at);
+
+CREATE TABLE IF NOT EXISTS orders (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	cart_id text,
+	user_id text,
+	store_id text,
+	total integer,
+	currency text,
+	item_count integer,
+	status text,
+	completed_at timestamp with time zone,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	store_id text,
+	product_id text,
+	quantity integer,
+	low_stock_threshold integer,
+	low_stock_alerted_at timestamp with time zone,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_levels_deleted_at ON stock_levels (deleted_at);
+
+CREATE TABLE IF NOT EXISTS store_zones (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	store_id text,
+	name text,
+	sequence integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_store_zones_deleted_at ON store_zones (deleted_at);
+
+CREATE TABLE IF NOT EXISTS aisles (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	store_id text,
+	zone_id text,
+	name text,
+	sequence integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_aisles_deleted_at ON aisles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bays (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	store_id text,
+	aisle_id text,
+	name text,
+	sequence integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_bays_deleted_at ON bays (deleted_at);
+
+CREATE TABLE IF NOT EXISTS product_placements (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	store_id text,
+	product_id text,
+	bay_id text,
+	shelf integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_placements_deleted_at ON product_placements (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shopping_lists (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	user_id text,
+	name text,
+	active boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shopping_lists_deleted_at ON shopping_lists (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shopping_list_entries (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	list_id text,
+	product_id text,
+	text text,
+	quantity integer,
+	checked boolean,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shopping_list_entries_deleted_at ON shopping_list_entries (deleted_at);
+`,
+	Down: `
+DROP TABLE IF EXISTS shopping_list_entries;
+DROP TABLE IF EXISTS shopping_lists;
+DROP TABLE IF EXISTS product_placements;
+DROP TABLE IF EXISTS bays;
+DROP TABLE IF EXISTS aisles;
+DROP TABLE IF EXISTS store_zones;
+DROP TABLE IF EXISTS stock_levels;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS store_holidays;
+DROP TABLE IF EXISTS store_hours;
+DROP TABLE IF EXISTS store_prices;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS stores;
+DROP TABLE IF EXISTS monthly_budgets;
+DROP TABLE IF EXISTS cart_template_items;
+DROP TABLE IF EXISTS cart_templates;
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
+DROP TABLE IF EXISTS audit_entries;
+DROP TABLE IF EXISTS email_changes;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS phone_otps;
+DROP TABLE IF EXISTS users;
+`,
+}
@@ -0,0 +1,68 @@
+package migrate
+
+// lookupIndexes adds the indexes AutoMigrate never created. Every lookup by
+// UUID, user and cart used to scan the whole table, and nothing stopped two
+// accounts from sharing an email. Creating a unique index fails while
+// duplicates exist, they have to be resolved by hand first.
+var lookupIndexes = Migration{
+	Version: 2,
+	Name:    "lookup_indexes",
+	Up: `
+-- Emails identify accounts, anonymised accounts get a unique placeholder
+CREATE UNIQUE INDEX uix_users_email ON users (email);
+
+CREATE UNIQUE INDEX uix_users_uuid ON users (uuid);
+CREATE UNIQUE INDEX uix_carts_uuid ON carts (uuid);
+CREATE UNIQUE INDEX uix_cart_items_uuid ON cart_items (uuid);
+CREATE UNIQUE INDEX uix_cart_templates_uuid ON cart_templates (uuid);
+CREATE UNIQUE INDEX uix_orders_uuid ON orders (uuid);
+CREATE UNIQUE INDEX uix_stores_uuid ON stores (uuid);
+CREATE UNIQUE INDEX uix_products_uuid ON products (uuid);
+CREATE UNIQUE INDEX uix_store_zones_uuid ON store_zones (uuid);
+CREATE UNIQUE INDEX uix_aisles_uuid ON aisles (uuid);
+CREATE UNIQUE INDEX uix_bays_uuid ON bays (uuid);
+CREATE UNIQUE INDEX uix_shopping_lists_uuid ON shopping_lists (uuid);
+CREATE UNIQUE INDEX uix_shopping_list_entries_uuid ON shopping_list_entries (uuid);
+
+CREATE INDEX idx_carts_user_id ON carts (user_id);
+CREATE INDEX idx_orders_user_id ON orders (user_id);
+CREATE INDEX idx_cart_templates_user_id ON cart_templates (user_id);
+CREATE INDEX idx_shopping_lists_user_id ON shopping_lists (user_id);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX idx_login_challenges_user_id ON login_challenges (user_id);
+CREATE INDEX idx_email_changes_user_id ON email_changes (user_id);
+CREATE INDEX idx_audit_entries_user_id ON audit_entries (user_id);
+
+CREATE INDEX idx_cart_items_cart_id ON cart_items (cart_id);
+CREATE INDEX idx_orders_cart_id ON orders (cart_id);
+CREATE INDEX idx_cart_template_items_template_id ON cart_template_items (template_id);
+CREATE INDEX idx_shopping_list_entries_list_id ON shopping_list_entries (list_id);
+`,
+	Down: `
+DROP INDEX IF EXISTS idx_shopping_list_entries_list_id;
+DROP INDEX IF EXISTS idx_cart_template_items_template_id;
+DROP INDEX IF EXISTS idx_orders_cart_id;
+DROP INDEX IF EXISTS idx_cart_items_cart_id;
+DROP INDEX IF EXISTS idx_audit_entries_user_id;
+DROP INDEX IF EXISTS idx_email_changes_user_id;
+DROP INDEX IF EXISTS idx_login_challenges_user_id;
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+DROP INDEX IF EXISTS idx_shopping_lists_user_id;
+DROP INDEX IF EXISTS idx_cart_templates_user_id;
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP INDEX IF EXISTS idx_carts_user_id;
+DROP INDEX IF EXISTS uix_shopping_list_entries_uuid;
+DROP INDEX IF EXISTS uix_shopping_lists_uuid;
+DROP INDEX IF EXISTS uix_bays_uuid;
+DROP INDEX IF EXISTS uix_aisles_uuid;
+DROP INDEX IF EXISTS uix_store_zones_uuid;
+DROP INDEX IF EXISTS uix_products_uuid;
+DROP INDEX IF EXISTS uix_stores_uuid;
+DROP INDEX IF EXISTS uix_orders_uuid;
+DROP INDEX IF EXISTS uix_cart_templates_uuid;
+DROP INDEX IF EXISTS uix_cart_items_uuid;
+DROP INDEX IF EXISTS uix_carts_uuid;
+DROP INDEX IF EXISTS uix_users_uuid;
+DROP INDEX IF EXISTS uix_users_email;
+`,
+}
@@ -0,0 +1,14 @@
+package migrate
+
+// userDisabledAt records when an account was disabled, disabled accounts
+// cannot log in.
+var userDisabledAt = Migration{
+	Version: 3,
+	Name:    "user_disabled_at",
+	Up: `
+ALTER TABLE users ADD COLUMN disabled_at timestamp with time zone;
+`,
+	Down: `
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+`,
+}
@@ -0,0 +1,16 @@
+package migrate
+
+// uniquePhoneNumbers makes phone numbers unique, they identify the account
+// for OTP login. Accounts without a number, including anonymised ones, have
+// it empty. Creating the index fails while two accounts share a number,
+// they have to be resolved by hand first.
+var uniquePhoneNumbers = Migration{
+	Version: 4,
+	Name:    "unique_phone_numbers",
+	Up: `
+CREATE UNIQUE INDEX uix_users_phone_number ON users (phone_number) WHERE phone_number <> '';
+`,
+	Down: `
+DROP INDEX IF EXISTS uix_users_phone_number;
+`,
+}
@@ -0,0 +1,14 @@
+package migrate
+
+// totpLastStep records the time step of the last TOTP code each user
+// logged in with, so that a code cannot be used twice.
+var totpLastStep = Migration{
+	Version: 5,
+	Name:    "totp_last_step",
+	Up: `
+ALTER TABLE users ADD COLUMN totp_last_step bigint NOT NULL DEFAULT 0;
+`,
+	Down: `
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+`,
+}
@@ -0,0 +1,31 @@
+package migrate
+
+// staffStores assigns staff to the store they manage and keeps a single
+// stock level per product and store. Stock levels created twice by
+// concurrent restocks are merged into the oldest one first.
+var staffStores = Migration{
+	Version: 6,
+	Name:    "staff_stores",
+	Up: `
+ALTER TABLE users ADD COLUMN store_id text NOT NULL DEFAULT '';
+
+UPDATE stock_levels s SET
+	quantity = d.quantity,
+	low_stock_threshold = d.low_stock_threshold
+FROM (
+	SELECT min(id) AS id, sum(quantity) AS quantity, max(low_stock_threshold) AS low_stock_threshold
+	FROM stock_levels
+	GROUP BY store_id, product_id
+	HAVING count(*) > 1
+) d
+WHERE s.id = d.id;
+DELETE FROM stock_levels s
+USING stock_levels keep
+WHERE keep.store_id = s.store_id AND keep.product_id = s.product_id AND keep.id < s.id;
+CREATE UNIQUE INDEX uix_stock_levels_store_product ON stock_levels (store_id, product_id);
+`,
+	Down: `
+DROP INDEX IF EXISTS uix_stock_levels_store_product;
+ALTER TABLE users DROP COLUMN IF EXISTS store_id;
+`,
+}
@@ -0,0 +1,42 @@
+package migrate
+
+// orderLines keeps a copy of the items of every order, so that reordering
+// and the analytics no longer depend on the cart, which can be deleted.
+// Existing orders are filled in from their carts where these still exist.
+var orderLines = Migration{
+	Version: 7,
+	Name:    "order_lines",
+	Up: `
+ALTER TABLE orders ADD COLUMN cart_name text NOT NULL DEFAULT '';
+UPDATE orders o SET cart_name = c.cart_name FROM carts c WHERE c.uuid = o.cart_id;
+
+CREATE TABLE order_lines (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	order_id text,
+	product_id text,
+	item_name text,
+	item_price integer,
+	item_quantity integer,
+	item_image_url text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_order_lines_deleted_at ON order_lines (deleted_at);
+CREATE INDEX idx_order_lines_order_id ON order_lines (order_id);
+
+-- Items trashed with their cart carry the deletion time of the cart
+INSERT INTO order_lines (created_at, updated_at, order_id, product_id, item_name, item_price, item_quantity, item_image_url)
+SELECT o.completed_at, o.completed_at, o.uuid, i.product_id, i.item_name, i.item_price, i.item_quantity, i.item_image_url
+FROM orders o
+JOIN carts c ON c.uuid = o.cart_id
+JOIN cart_items i ON i.cart_id = c.uuid
+WHERE i.deleted_at IS NULL OR i.deleted_at = c.deleted_at
+ORDER BY i.id;
+`,
+	Down: `
+DROP TABLE IF EXISTS order_lines;
+ALTER TABLE orders DROP COLUMN IF EXISTS cart_name;
+`,
+}
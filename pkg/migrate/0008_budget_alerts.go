@@ -0,0 +1,30 @@
+package migrate
+
+// budgetAlerts gives monthly budgets the timezone their months run in and
+// records the budget alerts sent, so that each is sent once per cart or
+// month.
+var budgetAlerts = Migration{
+	Version: 8,
+	Name:    "budget_alerts",
+	Up: `
+ALTER TABLE monthly_budgets ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
+
+CREATE TABLE budget_alerts (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	user_id text,
+	scope text,
+	period text,
+	threshold integer,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_budget_alerts_deleted_at ON budget_alerts (deleted_at);
+CREATE UNIQUE INDEX uix_budget_alerts_period ON budget_alerts (user_id, scope, period, threshold);
+`,
+	Down: `
+DROP TABLE IF EXISTS budget_alerts;
+ALTER TABLE monthly_budgets DROP COLUMN IF EXISTS timezone;
+`,
+}
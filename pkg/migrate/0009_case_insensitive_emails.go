@@ -0,0 +1,20 @@
+package migrate
+
+// caseInsensitiveEmails makes emails unique regardless of case. The old index
+// let "Ann@x.io" and "ann@x.io" register as two accounts. Stored emails are
+// lowercased first, accounts that only differ in case have to be merged by
+// hand before this runs.
+var caseInsensitiveEmails = Migration{
+	Version: 9,
+	Name:    "case_insensitive_emails",
+	Up: `
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+
+DROP INDEX IF EXISTS uix_users_email;
+CREATE UNIQUE INDEX uix_users_email_lower ON users (lower(email));
+`,
+	Down: `
+DROP INDEX IF EXISTS uix_users_email_lower;
+CREATE UNIQUE INDEX uix_users_email ON users (email);
+`,
+}
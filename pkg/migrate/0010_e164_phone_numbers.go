@@ -0,0 +1,17 @@
+package migrate
+
+// e164PhoneNumbers brings stored phone numbers to the E.164 form the user
+// service now normalises every number to. Numbers were stored as
+// international numbers with an optional leading plus, the ones without it
+// get one. Numbers that become equal have to be resolved by hand first,
+// the unique index refuses them.
+var e164PhoneNumbers = Migration{
+	Version: 10,
+	Name:    "e164_phone_numbers",
+	Up: `
+UPDATE users SET phone_number = '+' || phone_number WHERE phone_number ~ '^[1-9][0-9]{6,14}$';
+`,
+	Down: `
+-- Numbers with a leading plus were valid before as well, nothing to revert
+`,
+}
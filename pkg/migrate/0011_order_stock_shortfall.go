@@ -0,0 +1,18 @@
+package migrate
+
+// orderStockShortfall records on orders and their lines the stock the
+// store's records did not cover at checkout, which used to be dropped
+// silently.
+var orderStockShortfall = Migration{
+	Version: 11,
+	Name:    "order_stock_shortfall",
+	Up: `
+ALTER TABLE orders ADD COLUMN stock_shortfall boolean NOT NULL DEFAULT false;
+ALTER TABLE order_lines ADD COLUMN stock_shortfall integer NOT NULL DEFAULT 0;
+CREATE INDEX idx_orders_stock_shortfall ON orders (store_id) WHERE stock_shortfall;
+`,
+	Down: `
+ALTER TABLE order_lines DROP COLUMN IF EXISTS stock_shortfall;
+ALTER TABLE orders DROP COLUMN IF EXISTS stock_shortfall;
+`,
+}
@@ -0,0 +1,264 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New(nil, []Migration{{Version: 3, Name: "c"}, {Version: 1, Name: "a"}, {Version: 2, Name: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, mig := range m.migrations {
+		names = append(names, mig.Name)
+	}
+	if strings.Join(names, "") != "abc" {
+		t.Errorf("migrations in order %v, want by version", names)
+	}
+
+	if _, err := New(nil, []Migration{{Version: 1, Name: "a"}, {Version: 1, Name: "b"}}); err == nil {
+		t.Error("duplicate versions accepted")
+	}
+	if _, err := New(nil, []Migration{{Name: "a"}}); err == nil {
+		t.Error("migration without a version accepted")
+	}
+	if _, err := New(nil, []Migration{{Version: -1, Name: "a"}}); err == nil {
+		t.Error("negative version accepted")
+	}
+}
+
+func TestAll(t *testing.T) {
+	names := map[string]bool{}
+	for i, m := range All {
+		if m.Version != i+1 {
+			t.Errorf("%s has version %d, want %d as the %d. migration", m.Name, m.Version, i+1, i+1)
+		}
+		if names[m.Name] {
+			t.Errorf("name %s used twice", m.Name)
+		}
+		names[m.Name] = true
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("%s lacks an Up or Down script", m.Name)
+		}
+	}
+	if _, err := New(nil, All); err != nil {
+		t.Error(err)
+	}
+}
+
+// testDB connects to the database in TEST_DATABASE_URL with a schema of
+// its own on the search path, so the test starts from an empty database.
+// The test is skipped without one.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	admin, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("postgres", url)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	switch {
+	case !strings.Contains(url, "://"):
+		url += " search_path=" + schema
+	case strings.Contains(url, "?"):
+		url += "&search_path=" + schema
+	default:
+		url += "?search_path=" + schema
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// preSeries is the schema AutoMigrate created before the application got
+// its first tables for stores, two factor logins and the rest.
+const preSeries = `
+CREATE TABLE users (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	name text,
+	email text,
+	password text,
+	image_url text,
+	phone_number text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE carts (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	cart_name text,
+	user_id text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_carts_deleted_at ON carts (deleted_at);
+
+CREATE TABLE cart_items (
+	id serial,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	deleted_at timestamp with time zone,
+	uuid text,
+	cart_id text,
+	item_name text,
+	item_price integer,
+	item_quantity integer,
+	item_image_url text,
+	PRIMARY KEY (id)
+);
+CREATE INDEX idx_cart_items_deleted_at ON cart_items (deleted_at);
+
+INSERT INTO users (created_at, updated_at, uuid, name, email, password, phone_number)
+VALUES (now(), now(), 'u1', 'Ann', 'Ann@Example.com', 'hash', '447911123456');
+INSERT INTO carts (created_at, updated_at, uuid, cart_name, user_id) VALUES (now(), now(), 'c1', 'Weekly', 'u1');
+INSERT INTO cart_items (created_at, updated_at, uuid, cart_id, item_name, item_price, item_quantity)
+VALUES (now(), now(), 'i1', 'c1', 'Milk', 120, 2);
+`
+
+// TestUpPreSeries runs against the database in TEST_DATABASE_URL.
+func TestUpPreSeries(t *testing.T) {
+	db := testDB(t)
+	if _, err := db.Exec(preSeries); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(db, All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(All) {
+		t.Errorf("applied %d migrations, want %d", len(done), len(All))
+	}
+
+	// The columns added since exist and the data is kept
+	var email, phone, role string
+	var totp bool
+	err = db.QueryRow(`SELECT email, phone_number, COALESCE(role, ''), COALESCE(totp_enabled, false) FROM users WHERE uuid = 'u1'`).
+		Scan(&email, &phone, &role, &totp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "ann@example.com" || phone != "+447911123456" {
+		t.Errorf("user kept as %s %s, want the email lower cased and the number in E.164", email, phone)
+	}
+	var storeID, productID sql.NullString
+	err = db.QueryRow(`SELECT c.store_id, i.product_id FROM carts c JOIN cart_items i ON i.cart_id = c.uuid
+WHERE c.uuid = 'c1' AND c.archived_at IS NULL AND c.checked_out_at IS NULL AND COALESCE(c.budget, 0) = 0`).
+		Scan(&storeID, &productID)
+	if err != nil {
+		t.Fatalf("cart columns: %v", err)
+	}
+
+	if again, err := m.Up(); err != nil || len(again) != 0 {
+		t.Errorf("second Up applied %d migrations, %v", len(again), err)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("%d %s not recorded as applied", s.Version, s.Name)
+		}
+	}
+}
+
+// TestDownUp runs against the database in TEST_DATABASE_URL.
+func TestDownUp(t *testing.T) {
+	db := testDB(t)
+	m, err := New(db, All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	done, err := m.Down(len(All))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(All) || done[0].Version != len(All) {
+		t.Errorf("Down reverted %d migrations starting at %d, want all newest first", len(done), done[0].Version)
+	}
+	var tables int
+	if err := db.QueryRow(`SELECT count(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name <> 'schema_migrations'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("%d tables left after reverting everything", tables)
+	}
+	if done, err := m.Up(); err != nil || len(done) != len(All) {
+		t.Errorf("Up after Down applied %d migrations, %v", len(done), err)
+	}
+}
+
+// TestUpConcurrently runs against the database in TEST_DATABASE_URL.
+func TestUpConcurrently(t *testing.T) {
+	db := testDB(t)
+	var wg sync.WaitGroup
+	applied := make([]int, 4)
+	errs := make([]error, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := New(db, All)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			done, err := m.Up()
+			applied[i], errs[i] = len(done), err
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range applied {
+		if errs[i] != nil {
+			t.Errorf("instance %d: %v", i, errs[i])
+		}
+		total += applied[i]
+	}
+	if total != len(All) {
+		t.Errorf("instances applied %d migrations between them, want each of the %d once", total, len(All))
+	}
+}
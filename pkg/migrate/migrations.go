@@ -0,0 +1,17 @@
+package migrate
+
+// All lists the migrations of the application. New migrations are added at
+// the end with the next version, applied ones are never edited.
+var All = []Migration{
+	baseline,
+	lookupIndexes,
+	userDisabledAt,
+	uniquePhoneNumbers,
+	totpLastStep,
+	staffStores,
+	orderLines,
+	budgetAlerts,
+	caseInsensitiveEmails,
+	e164PhoneNumbers,
+	orderStockShortfall,
+}
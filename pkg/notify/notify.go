@@ -0,0 +1,22 @@
+package notify
+
+import "log"
+
+// Notifier delivers push style notifications. Recipients are user IDs or
+// topics such as "store:<id>" for staff alerts.
+type Notifier interface {
+	Notify(recipient, title, message string) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a Notifier that only writes to the log, meant for
+// local runs where no push provider is configured.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(recipient, title, message string) error {
+	log.Printf("Notification to %s: %s - %s", recipient, title, message)
+	return nil
+}
@@ -0,0 +1,190 @@
+package shoppinglist
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type Repository interface {
+	CreateList(list *entities.ShoppingList) (*entities.ShoppingList, error)
+
+	FindList(listID string) (*entities.ShoppingList, error)
+
+	FindActiveList(userID string) (*entities.ShoppingList, error)
+
+	GetLists(userID string) (*[]entities.ShoppingList, error)
+
+	// Activate makes the list the only active list of its user.
+	Activate(list *entities.ShoppingList) error
+
+	DeleteList(list *entities.ShoppingList) error
+
+	CreateEntry(entry *entities.ShoppingListEntry) (*entities.ShoppingListEntry, error)
+
+	FindEntry(entryID string) (*entities.ShoppingListEntry, error)
+
+	SaveEntry(entry *entities.ShoppingListEntry) error
+
+	DeleteEntry(entry *entities.ShoppingListEntry) error
+
+	GetEntries(listID string) (*[]entities.ShoppingListEntry, error)
+
+	PurgeUserLists(userID string) error
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) CreateList(list *entities.ShoppingList) (*entities.ShoppingList, error) {
+	result := r.DB.Create(list)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return list, nil
+}
+
+func (r *repo) FindList(listID string) (*entities.ShoppingList, error) {
+	list := &entities.ShoppingList{}
+	result := r.DB.Where("uuid = ?", listID).First(list)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return list, nil
+}
+
+func (r *repo) FindActiveList(userID string) (*entities.ShoppingList, error) {
+	list := &entities.ShoppingList{}
+	result := r.DB.Where("user_id = ? AND active = ?", userID, true).First(list)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return list, nil
+}
+
+func (r *repo) GetLists(userID string) (*[]entities.ShoppingList, error) {
+	var lists []entities.ShoppingList
+	err := r.DB.Where("user_id = ?", userID).Order("updated_at desc").Find(&lists).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &lists, nil
+}
+
+func (r *repo) Activate(list *entities.ShoppingList) error {
+	tx := r.DB.Begin()
+	err := tx.Model(&entities.ShoppingList{}).
+		Where("user_id = ? AND uuid <> ?", list.UserID, list.UUID).
+		Update("active", false).Error
+	if err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	list.Active = true
+	if err := tx.Save(list).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) DeleteList(list *entities.ShoppingList) error {
+	tx := r.DB.Begin()
+	if err := tx.Where("list_id = ?", list.UUID).Delete(&entities.ShoppingListEntry{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Delete(list).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) CreateEntry(entry *entities.ShoppingListEntry) (*entities.ShoppingListEntry, error) {
+	result := r.DB.Create(entry)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return entry, nil
+}
+
+func (r *repo) FindEntry(entryID string) (*entities.ShoppingListEntry, error) {
+	entry := &entities.ShoppingListEntry{}
+	result := r.DB.Where("uuid = ?", entryID).First(entry)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return entry, nil
+}
+
+func (r *repo) SaveEntry(entry *entities.ShoppingListEntry) error {
+	err := r.DB.Save(entry).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) DeleteEntry(entry *entities.ShoppingListEntry) error {
+	err := r.DB.Delete(entry).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) GetEntries(listID string) (*[]entities.ShoppingListEntry, error) {
+	var entries []entities.ShoppingListEntry
+	err := r.DB.Where("list_id = ?", listID).Order("created_at").Find(&entries).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &entries, nil
+}
+
+func (r *repo) PurgeUserLists(userID string) error {
+	tx := r.DB.Begin()
+	var listIDs []string
+	if err := tx.Unscoped().Model(&entities.ShoppingList{}).Where("user_id = ?", userID).Pluck("uuid", &listIDs).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("list_id IN (?)", listIDs).Delete(&entities.ShoppingListEntry{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.ShoppingList{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
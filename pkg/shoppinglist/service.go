@@ -0,0 +1,244 @@
+package shoppinglist
+
+import (
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"strings"
+)
+
+// EntryProgress is how much of a list entry has been scanned so far.
+type EntryProgress struct {
+	entities.ShoppingListEntry
+	Scanned   int `json:"scanned"`
+	Remaining int `json:"remaining"`
+}
+
+// MatchResult splits a list into what is already in the cart and what is
+// still missing.
+type MatchResult struct {
+	ListID  string          `json:"list_id"`
+	Found   []EntryProgress `json:"found"`
+	Missing []EntryProgress `json:"missing"`
+}
+
+type Service interface {
+	CreateList(list *entities.ShoppingList) (*entities.ShoppingList, error)
+
+	GetList(listID, userID string) (*entities.ShoppingList, error)
+
+	GetActiveList(userID string) (*entities.ShoppingList, error)
+
+	GetLists(userID string) (*[]entities.ShoppingList, error)
+
+	SetActive(listID, userID string) (*entities.ShoppingList, error)
+
+	DeleteList(listID, userID string) error
+
+	AddEntry(entry *entities.ShoppingListEntry, userID string) (*entities.ShoppingListEntry, error)
+
+	SetChecked(entryID, userID string, checked bool) (*entities.ShoppingListEntry, error)
+
+	RemoveEntry(entryID, userID string) error
+
+	GetEntries(listID, userID string) (*[]entities.ShoppingListEntry, error)
+
+	// Match compares the scanned cart items with the list. Checked off
+	// entries count as found regardless of the cart.
+	Match(listID, userID string, items []entities.CartItem) (*MatchResult, error)
+
+	// DeleteUserData permanently removes every list of the user.
+	DeleteUserData(userID string) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+func (s *service) CreateList(list *entities.ShoppingList) (*entities.ShoppingList, error) {
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		return nil, pkg.ErrName
+	}
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	list.UUID = uuid.String()
+	wantActive := list.Active
+	list.Active = false
+
+	list, err = s.repo.CreateList(list)
+	if err != nil {
+		return nil, err
+	}
+	if wantActive {
+		if err := s.repo.Activate(list); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (s *service) GetList(listID, userID string) (*entities.ShoppingList, error) {
+	list, err := s.repo.FindList(listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.UserID != userID {
+		return nil, pkg.ErrForbidden
+	}
+	return list, nil
+}
+
+func (s *service) GetActiveList(userID string) (*entities.ShoppingList, error) {
+	return s.repo.FindActiveList(userID)
+}
+
+func (s *service) GetLists(userID string) (*[]entities.ShoppingList, error) {
+	return s.repo.GetLists(userID)
+}
+
+func (s *service) SetActive(listID, userID string) (*entities.ShoppingList, error) {
+	list, err := s.GetList(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Activate(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *service) DeleteList(listID, userID string) error {
+	list, err := s.GetList(listID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteList(list)
+}
+
+func (s *service) AddEntry(entry *entities.ShoppingListEntry, userID string) (*entities.ShoppingListEntry, error) {
+	if _, err := s.GetList(entry.ListID, userID); err != nil {
+		return nil, err
+	}
+	entry.Text = strings.TrimSpace(entry.Text)
+	if entry.Text == "" && entry.ProductID == "" {
+		return nil, pkg.ErrNotAllowed
+	}
+	if entry.Quantity <= 0 {
+		entry.Quantity = 1
+	}
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	entry.UUID = uuid.String()
+	return s.repo.CreateEntry(entry)
+}
+
+// ownedEntry loads an entry after checking that its list belongs to the user.
+func (s *service) ownedEntry(entryID, userID string) (*entities.ShoppingListEntry, error) {
+	entry, err := s.repo.FindEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetList(entry.ListID, userID); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) SetChecked(entryID, userID string, checked bool) (*entities.ShoppingListEntry, error) {
+	entry, err := s.ownedEntry(entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry.Checked = checked
+	if err := s.repo.SaveEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) RemoveEntry(entryID, userID string) error {
+	entry, err := s.ownedEntry(entryID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteEntry(entry)
+}
+
+func (s *service) GetEntries(listID, userID string) (*[]entities.ShoppingListEntry, error) {
+	if _, err := s.GetList(listID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetEntries(listID)
+}
+
+func (s *service) Match(listID, userID string, items []entities.CartItem) (*MatchResult, error) {
+	entries, err := s.GetEntries(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each scanned unit can satisfy only one entry, so quantities are
+	// consumed as entries are matched.
+	available := make([]int, len(items))
+	for i, item := range items {
+		available[i] = item.ItemQuantity
+	}
+
+	result := &MatchResult{
+		ListID:  listID,
+		Found:   []EntryProgress{},
+		Missing: []EntryProgress{},
+	}
+	for _, entry := range *entries {
+		progress := EntryProgress{ShoppingListEntry: entry}
+		for i, item := range items {
+			if progress.Scanned >= entry.Quantity {
+				break
+			}
+			if available[i] == 0 || !matches(entry, item) {
+				continue
+			}
+			take := entry.Quantity - progress.Scanned
+			if take > available[i] {
+				take = available[i]
+			}
+			available[i] -= take
+			progress.Scanned += take
+		}
+		progress.Remaining = entry.Quantity - progress.Scanned
+
+		if entry.Checked || progress.Remaining <= 0 {
+			progress.Remaining = 0
+			result.Found = append(result.Found, progress)
+		} else {
+			result.Missing = append(result.Missing, progress)
+		}
+	}
+	return result, nil
+}
+
+func (s *service) DeleteUserData(userID string) error {
+	return s.repo.PurgeUserLists(userID)
+}
+
+// matches links an entry to a scanned item, by product for catalog linked
+// entries and by a case-insensitive name match for free text ones.
+func matches(entry entities.ShoppingListEntry, item entities.CartItem) bool {
+	if entry.ProductID != "" {
+		return entry.ProductID == item.ProductID
+	}
+	text := strings.ToLower(entry.Text)
+	name := strings.ToLower(item.ItemName)
+	return text != "" && name != "" && (strings.Contains(name, text) || strings.Contains(text, name))
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between two points using
+// the haversine formula.
+func DistanceKm(a, b entities.LatLng) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Contains reports whether the point lies inside the polygon, using ray
+// casting. Stores are small enough to treat coordinates as planar.
+func Contains(polygon entities.Polygon, p entities.LatLng) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+			p.Longitude < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// boundingBox returns the corners of a box that encloses the circle of the
+// given radius around center, used to pre-filter stores in SQL.
+func boundingBox(center entities.LatLng, radiusKm float64) (min, max entities.LatLng) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	dLng := dLat / math.Max(math.Cos(center.Latitude*math.Pi/180), 0.01)
+	min = entities.LatLng{Latitude: center.Latitude - dLat, Longitude: center.Longitude - dLng}
+	max = entities.LatLng{Latitude: center.Latitude + dLat, Longitude: center.Longitude + dLng}
+	return min, max
+}
+
+func validCoordinates(p entities.LatLng) bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
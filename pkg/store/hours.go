@@ -0,0 +1,130 @@
+package store
+
+import (
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// How far ahead to search for the next opening before giving up.
+	lookaheadDays = 14
+)
+
+// OpenStatus describes whether a store is open at a point in time. Times
+// are in the store's timezone.
+type OpenStatus struct {
+	Open        bool       `json:"open"`
+	ClosesAt    *time.Time `json:"closes_at,omitempty"`
+	NextOpening *time.Time `json:"next_opening,omitempty"`
+	Timezone    string     `json:"timezone"`
+}
+
+type window struct {
+	opens, closes time.Time
+}
+
+// parseClock parses "HH:MM" into minutes since midnight, allowing "24:00".
+func parseClock(s string) (int, bool) {
+	if s == "24:00" {
+		return 24 * 60, true
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// validateWindow accepts a window closing before it opens as one that runs
+// past midnight, like 18:00 to 02:00.
+func validateWindow(opens, closes string) error {
+	o, ok1 := parseClock(opens)
+	c, ok2 := parseClock(closes)
+	if !ok1 || !ok2 || o == c || o == 24*60 {
+		return pkg.ErrOpeningHours
+	}
+	return nil
+}
+
+// at returns the wall clock time on day. Building it from the hour and
+// minute keeps it right on days a DST change makes shorter or longer.
+func at(day time.Time, clock string) time.Time {
+	m, _ := parseClock(clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), m/60, m%60, 0, 0, day.Location())
+}
+
+// newWindow returns the window opening on day, closing the next day when it
+// runs past midnight.
+func newWindow(day time.Time, opens, closes string) window {
+	o, _ := parseClock(opens)
+	c, _ := parseClock(closes)
+	if c < o {
+		return window{at(day, opens), at(day.AddDate(0, 0, 1), closes)}
+	}
+	return window{at(day, opens), at(day, closes)}
+}
+
+// windowsOn returns the opening windows starting on the given local day. A
+// holiday on that date replaces the weekly hours, a window of the day
+// before that runs past midnight is kept.
+func windowsOn(day time.Time, hours []entities.StoreHours, holidays map[string]entities.StoreHoliday) []window {
+	if h, ok := holidays[day.Format(dateLayout)]; ok {
+		if h.Closed {
+			return nil
+		}
+		return []window{newWindow(day, h.Opens, h.Closes)}
+	}
+	var windows []window
+	for _, h := range hours {
+		if h.Weekday == day.Weekday() {
+			windows = append(windows, newWindow(day, h.Opens, h.Closes))
+		}
+	}
+	return windows
+}
+
+// computeOpenStatus works out whether the store is open at now. A store
+// without any configured hours is considered always open.
+func computeOpenStatus(loc *time.Location, hours []entities.StoreHours, holidays []entities.StoreHoliday, now time.Time) *OpenStatus {
+	status := &OpenStatus{Timezone: loc.String()}
+	if len(hours) == 0 && len(holidays) == 0 {
+		status.Open = true
+		return status
+	}
+
+	byDate := make(map[string]entities.StoreHoliday, len(holidays))
+	for _, h := range holidays {
+		byDate[h.Date] = h
+	}
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	// Yesterday's windows may still be open after midnight
+	current := append(windowsOn(today.AddDate(0, 0, -1), hours, byDate), windowsOn(today, hours, byDate)...)
+	for _, w := range current {
+		if !now.Before(w.opens) && now.Before(w.closes) {
+			closes := w.closes
+			status.Open = true
+			status.ClosesAt = &closes
+			return status
+		}
+	}
+
+	for i := 0; i <= lookaheadDays; i++ {
+		day := today.AddDate(0, 0, i)
+		var next *time.Time
+		for _, w := range windowsOn(day, hours, byDate) {
+			if w.opens.After(now) && (next == nil || w.opens.Before(*next)) {
+				opens := w.opens
+				next = &opens
+			}
+		}
+		if next != nil {
+			status.NextOpening = next
+			break
+		}
+	}
+	return status
+}
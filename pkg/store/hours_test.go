@@ -0,0 +1,125 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func TestValidateWindow(t *testing.T) {
+	tests := []struct {
+		opens, closes string
+		ok            bool
+	}{
+		{"09:00", "17:00", true},
+		{"00:00", "24:00", true},
+		{"18:00", "02:00", true},
+		{"18:00", "00:00", true},
+		{"09:00", "09:00", false},
+		{"24:00", "02:00", false},
+		{"9am", "17:00", false},
+		{"09:00", "25:00", false},
+		{"", "17:00", false},
+	}
+	for _, tt := range tests {
+		if err := validateWindow(tt.opens, tt.closes); (err == nil) != tt.ok {
+			t.Errorf("validateWindow(%q, %q) = %v, want ok %v", tt.opens, tt.closes, err, tt.ok)
+		}
+	}
+}
+
+func TestComputeOpenStatus(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip(err)
+	}
+	local := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	hours := []entities.StoreHours{
+		{Weekday: time.Monday, Opens: "09:00", Closes: "12:00"},
+		{Weekday: time.Monday, Opens: "13:00", Closes: "17:00"},
+		{Weekday: time.Friday, Opens: "18:00", Closes: "02:00"},
+		{Weekday: time.Sunday, Opens: "10:00", Closes: "16:00"},
+		{Weekday: time.Tuesday, Opens: "20:00", Closes: "24:00"},
+	}
+	tests := []struct {
+		name     string
+		holidays []entities.StoreHoliday
+		now      string
+		open     bool
+		// closes or the next opening
+		at string
+	}{
+		{"open", nil, "2026-10-19 10:00", true, "2026-10-19 12:00"},
+		{"before opening", nil, "2026-10-19 08:00", false, "2026-10-19 09:00"},
+		{"lunch break", nil, "2026-10-19 12:00", false, "2026-10-19 13:00"},
+		{"after closing", nil, "2026-10-19 17:00", false, "2026-10-20 20:00"},
+		{"until midnight", nil, "2026-10-20 23:59", true, "2026-10-21 00:00"},
+		{"overnight before midnight", nil, "2026-10-23 23:00", true, "2026-10-24 02:00"},
+		{"overnight after midnight", nil, "2026-10-24 01:00", true, "2026-10-24 02:00"},
+		{"after overnight", nil, "2026-10-24 02:00", false, "2026-10-25 10:00"},
+		{"closed holiday", []entities.StoreHoliday{{Date: "2026-10-26", Closed: true}}, "2026-10-26 10:00", false, "2026-10-27 20:00"},
+		{"holiday hours closed", []entities.StoreHoliday{{Date: "2026-10-26", Opens: "11:00", Closes: "14:00"}}, "2026-10-26 09:30", false, "2026-10-26 11:00"},
+		{"holiday hours open", []entities.StoreHoliday{{Date: "2026-10-26", Opens: "11:00", Closes: "14:00"}}, "2026-10-26 13:30", true, "2026-10-26 14:00"},
+		{"holiday keeps last night open", []entities.StoreHoliday{{Date: "2026-10-24", Closed: true}}, "2026-10-24 01:00", true, "2026-10-24 02:00"},
+		{"overnight holiday", []entities.StoreHoliday{{Date: "2026-10-22", Opens: "22:00", Closes: "03:00"}}, "2026-10-23 02:00", true, "2026-10-23 03:00"},
+		{"clocks go back", nil, "2026-10-25 09:00", false, "2026-10-25 10:00"},
+		{"clocks go forward", nil, "2026-03-29 12:00", true, "2026-03-29 16:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := computeOpenStatus(loc, hours, tt.holidays, local(tt.now).UTC())
+			if s.Open != tt.open || s.Timezone != "Europe/London" {
+				t.Fatalf("status %+v, want open %v", s, tt.open)
+			}
+			got := s.NextOpening
+			if tt.open {
+				got = s.ClosesAt
+			}
+			if got == nil || !got.Equal(local(tt.at)) {
+				t.Errorf("got %v, want %s", got, tt.at)
+			}
+		})
+	}
+}
+
+func TestComputeOpenStatusDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip(err)
+	}
+	hours := []entities.StoreHours{{Weekday: time.Sunday, Opens: "10:00", Closes: "16:00"}}
+
+	// 10:00 BST on the day the clocks go forward is 09:00 UTC
+	s := computeOpenStatus(loc, hours, nil, time.Date(2026, 3, 29, 8, 30, 0, 0, time.UTC))
+	if s.Open || s.NextOpening == nil || !s.NextOpening.Equal(time.Date(2026, 3, 29, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("spring: %+v, want opening at 09:00 UTC", s)
+	}
+	// and 10:00 GMT on the day they go back is 10:00 UTC
+	s = computeOpenStatus(loc, hours, nil, time.Date(2026, 10, 25, 9, 30, 0, 0, time.UTC))
+	if s.Open || s.NextOpening == nil || !s.NextOpening.Equal(time.Date(2026, 10, 25, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("autumn: %+v, want opening at 10:00 UTC", s)
+	}
+}
+
+func TestComputeOpenStatusWithoutHours(t *testing.T) {
+	s := computeOpenStatus(time.UTC, nil, nil, time.Now())
+	if !s.Open || s.ClosesAt != nil || s.NextOpening != nil {
+		t.Errorf("store without hours: %+v, want always open", s)
+	}
+}
+
+func TestComputeOpenStatusNeverOpens(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	holidays := []entities.StoreHoliday{{Date: "2026-10-19", Closed: true}}
+	s := computeOpenStatus(time.UTC, nil, holidays, now)
+	if s.Open || s.NextOpening != nil {
+		t.Errorf("closed store: %+v, want no next opening", s)
+	}
+}
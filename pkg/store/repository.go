@@ -0,0 +1,152 @@
+package store
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+type Repository interface {
+	CreateStore(store *entities.Store) (*entities.Store, error)
+
+	FindByUUID(uuid string) (*entities.Store, error)
+
+	GetStores() (*[]entities.Store, error)
+
+	GetStoresInBox(min, max entities.LatLng) (*[]entities.Store, error)
+
+	SaveStore(store *entities.Store) error
+
+	GetHours(storeID string) (*[]entities.StoreHours, error)
+
+	ReplaceHours(storeID string, hours []entities.StoreHours) error
+
+	GetHolidays(storeID, fromDate, toDate string) (*[]entities.StoreHoliday, error)
+
+	SaveHoliday(holiday *entities.StoreHoliday) (*entities.StoreHoliday, error)
+
+	DeleteHoliday(storeID, date string) error
+}
+
+type repo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repository {
+	return &repo{
+		DB: db,
+	}
+}
+
+func (r *repo) CreateStore(store *entities.Store) (*entities.Store, error) {
+	result := r.DB.Create(store)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return store, nil
+}
+
+func (r *repo) FindByUUID(uuid string) (*entities.Store, error) {
+	store := &entities.Store{}
+	result := r.DB.Where("uuid = ?", uuid).First(store)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return store, nil
+}
+
+func (r *repo) GetStores() (*[]entities.Store, error) {
+	var stores []entities.Store
+	err := r.DB.Order("name").Find(&stores).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &stores, nil
+}
+
+func (r *repo) GetStoresInBox(min, max entities.LatLng) (*[]entities.Store, error) {
+	var stores []entities.Store
+	err := r.DB.Where(
+		"latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
+		min.Latitude, max.Latitude, min.Longitude, max.Longitude,
+	).Find(&stores).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &stores, nil
+}
+
+func (r *repo) SaveStore(store *entities.Store) error {
+	err := r.DB.Save(store).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) GetHours(storeID string) (*[]entities.StoreHours, error) {
+	var hours []entities.StoreHours
+	err := r.DB.Where("store_id = ?", storeID).Order("weekday, opens").Find(&hours).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &hours, nil
+}
+
+func (r *repo) ReplaceHours(storeID string, hours []entities.StoreHours) error {
+	tx := r.DB.Begin()
+	if err := tx.Unscoped().Where("store_id = ?", storeID).Delete(&entities.StoreHours{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	for i := range hours {
+		hours[i].StoreID = storeID
+		if err := tx.Create(&hours[i]).Error; err != nil {
+			tx.Rollback()
+			return pkg.ErrDatabase
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) GetHolidays(storeID, fromDate, toDate string) (*[]entities.StoreHoliday, error) {
+	var holidays []entities.StoreHoliday
+	err := r.DB.Where("store_id = ? AND date BETWEEN ? AND ?", storeID, fromDate, toDate).
+		Order("date").Find(&holidays).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &holidays, nil
+}
+
+func (r *repo) SaveHoliday(holiday *entities.StoreHoliday) (*entities.StoreHoliday, error) {
+	existing := &entities.StoreHoliday{}
+	err := r.DB.Where("store_id = ? AND date = ?", holiday.StoreID, holiday.Date).First(existing).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, pkg.ErrDatabase
+	}
+	holiday.ID = existing.ID
+	holiday.CreatedAt = existing.CreatedAt
+	if err := r.DB.Save(holiday).Error; err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return holiday, nil
+}
+
+func (r *repo) DeleteHoliday(storeID, date string) error {
+	result := r.DB.Unscoped().Where("store_id = ? AND date = ?", storeID, date).Delete(&entities.StoreHoliday{})
+	if result.Error != nil {
+		return pkg.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		return pkg.ErrNotFound
+	}
+	return nil
+}
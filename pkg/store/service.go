@@ -0,0 +1,247 @@
+package store
+
+import (
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+const (
+	DefaultNearbyRadiusKm = 5.0
+	MaxNearbyRadiusKm     = 50.0
+	// Stores without a geofence accept carts started this close to them.
+	fallbackFenceKm = 0.2
+)
+
+// NearbyStore is a store with its distance from the point searched from.
+type NearbyStore struct {
+	entities.Store
+	DistanceKm float64 `json:"distance_km"`
+	Inside     bool    `json:"inside"`
+}
+type Service interface {
+	CreateStore(store *entities.Store) (*entities.Store, error)
+
+	GetStore(storeID string) (*entities.Store, error)
+
+	GetStores() (*[]entities.Store, error)
+
+	// NearbyStores returns the stores within radiusKm of the point,
+	// closest first.
+	NearbyStores(at entities.LatLng, radiusKm float64) ([]NearbyStore, error)
+
+	SetGeofence(storeID string, fence entities.Polygon) (*entities.Store, error)
+
+	// IsInside reports whether the point is within the store's geofence.
+	IsInside(store *entities.Store, at entities.LatLng) bool
+
+	GetHours(storeID string) (*[]entities.StoreHours, error)
+
+	SetHours(storeID string, hours []entities.StoreHours) (*[]entities.StoreHours, error)
+
+	// GetHolidays returns the holiday overrides between two dates,
+	// inclusive, formatted as YYYY-MM-DD.
+	GetHolidays(storeID, fromDate, toDate string) (*[]entities.StoreHoliday, error)
+
+	SetHoliday(holiday *entities.StoreHoliday) (*entities.StoreHoliday, error)
+
+	RemoveHoliday(storeID, date string) error
+
+	// OpenStatus reports whether the store is open at now and, if not,
+	// when it opens next.
+	OpenStatus(store *entities.Store, now time.Time) (*OpenStatus, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repo: r,
+	}
+}
+
+func Validate(store *entities.Store) error {
+	if _, err := time.LoadLocation(store.Timezone); err != nil || store.Timezone == "" {
+		return pkg.ErrTimezone
+	}
+	if !currencyRegexp.MatchString(store.Currency) {
+		return pkg.ErrCurrency
+	}
+	if !validCoordinates(entities.LatLng{Latitude: store.Latitude, Longitude: store.Longitude}) {
+		return pkg.ErrCoordinates
+	}
+	if len(store.Geofence) > 0 {
+		return validateFence(store.Geofence)
+	}
+	return nil
+}
+
+func validateFence(fence entities.Polygon) error {
+	if len(fence) < 3 {
+		return pkg.ErrGeofence
+	}
+	for _, p := range fence {
+		if !validCoordinates(p) {
+			return pkg.ErrGeofence
+		}
+	}
+	return nil
+}
+
+func (s *service) CreateStore(store *entities.Store) (*entities.Store, error) {
+	if err := Validate(store); err != nil {
+		return nil, err
+	}
+	uuid, err := uuid2.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	store.UUID = uuid.String()
+
+	return s.repo.CreateStore(store)
+}
+
+func (s *service) GetStore(storeID string) (*entities.Store, error) {
+	return s.repo.FindByUUID(storeID)
+}
+
+func (s *service) GetStores() (*[]entities.Store, error) {
+	return s.repo.GetStores()
+}
+
+func (s *service) NearbyStores(at entities.LatLng, radiusKm float64) ([]NearbyStore, error) {
+	if !validCoordinates(at) {
+		return nil, pkg.ErrCoordinates
+	}
+	if radiusKm <= 0 {
+		radiusKm = DefaultNearbyRadiusKm
+	}
+	if radiusKm > MaxNearbyRadiusKm {
+		radiusKm = MaxNearbyRadiusKm
+	}
+
+	min, max := boundingBox(at, radiusKm)
+	stores, err := s.repo.GetStoresInBox(min, max)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := make([]NearbyStore, 0, len(*stores))
+	for _, st := range *stores {
+		d := DistanceKm(at, entities.LatLng{Latitude: st.Latitude, Longitude: st.Longitude})
+		if d > radiusKm {
+			continue
+		}
+		nearby = append(nearby, NearbyStore{
+			Store:      st,
+			DistanceKm: d,
+			Inside:     s.IsInside(&st, at),
+		})
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+	return nearby, nil
+}
+
+func (s *service) SetGeofence(storeID string, fence entities.Polygon) (*entities.Store, error) {
+	if err := validateFence(fence); err != nil {
+		return nil, err
+	}
+	store, err := s.repo.FindByUUID(storeID)
+	if err != nil {
+		return nil, err
+	}
+	store.Geofence = fence
+	if err := s.repo.SaveStore(store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *service) IsInside(store *entities.Store, at entities.LatLng) bool {
+	if len(store.Geofence) >= 3 {
+		return Contains(store.Geofence, at)
+	}
+	center := entities.LatLng{Latitude: store.Latitude, Longitude: store.Longitude}
+	return DistanceKm(center, at) <= fallbackFenceKm
+}
+
+func (s *service) GetHours(storeID string) (*[]entities.StoreHours, error) {
+	return s.repo.GetHours(storeID)
+}
+
+func (s *service) SetHours(storeID string, hours []entities.StoreHours) (*[]entities.StoreHours, error) {
+	if _, err := s.repo.FindByUUID(storeID); err != nil {
+		return nil, err
+	}
+	for _, h := range hours {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+			return nil, pkg.ErrOpeningHours
+		}
+		if err := validateWindow(h.Opens, h.Closes); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.ReplaceHours(storeID, hours); err != nil {
+		return nil, err
+	}
+	return s.repo.GetHours(storeID)
+}
+
+func (s *service) GetHolidays(storeID, fromDate, toDate string) (*[]entities.StoreHoliday, error) {
+	if _, err := time.Parse(dateLayout, fromDate); err != nil {
+		return nil, pkg.ErrDate
+	}
+	if _, err := time.Parse(dateLayout, toDate); err != nil {
+		return nil, pkg.ErrDate
+	}
+	return s.repo.GetHolidays(storeID, fromDate, toDate)
+}
+
+func (s *service) SetHoliday(holiday *entities.StoreHoliday) (*entities.StoreHoliday, error) {
+	if _, err := s.repo.FindByUUID(holiday.StoreID); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(dateLayout, holiday.Date); err != nil {
+		return nil, pkg.ErrDate
+	}
+	if !holiday.Closed {
+		if err := validateWindow(holiday.Opens, holiday.Closes); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.SaveHoliday(holiday)
+}
+
+func (s *service) RemoveHoliday(storeID, date string) error {
+	return s.repo.DeleteHoliday(storeID, date)
+}
+
+func (s *service) OpenStatus(store *entities.Store, now time.Time) (*OpenStatus, error) {
+	loc, err := time.LoadLocation(store.Timezone)
+	if err != nil {
+		return nil, pkg.ErrTimezone
+	}
+	hours, err := s.repo.GetHours(store.UUID)
+	if err != nil {
+		return nil, err
+	}
+	local := now.In(loc)
+	holidays, err := s.repo.GetHolidays(
+		store.UUID,
+		local.AddDate(0, 0, -1).Format(dateLayout),
+		local.AddDate(0, 0, lookaheadDays).Format(dateLayout),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return computeOpenStatus(loc, *hours, *holidays, now), nil
+}
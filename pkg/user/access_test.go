@@ -0,0 +1,78 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func TestCheckAccess(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo, &sentSMS{})
+	now := time.Now()
+	users := []entities.User{
+		{UUID: "shopper", Email: "a@example.com", Role: entities.RoleUser},
+		{UUID: "legacy", Email: "b@example.com"},
+		{UUID: "staff", Email: "c@example.com", Role: entities.RoleStaff, StoreID: "s1"},
+		{UUID: "disabled", Email: "d@example.com", Role: entities.RoleUser, DisabledAt: &now},
+	}
+	for i := range users {
+		if _, err := repo.Register(&users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name                string
+		uuid, role, storeID string
+		want                error
+	}{
+		{"matching claims", "shopper", entities.RoleUser, "", nil},
+		{"account from before roles", "legacy", entities.RoleUser, "", nil},
+		{"staff of the store", "staff", entities.RoleStaff, "s1", nil},
+		{"role changed", "shopper", entities.RoleAdmin, "", pkg.ErrStaleToken},
+		{"moved store", "staff", entities.RoleStaff, "s2", pkg.ErrStaleToken},
+		{"disabled", "disabled", entities.RoleUser, "", pkg.ErrDisabled},
+		{"deleted", "nobody", entities.RoleUser, "", pkg.ErrStaleToken},
+	}
+	for _, tt := range tests {
+		if err := s.CheckAccess(tt.uuid, tt.role, tt.storeID); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: CheckAccess = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A failing database must not let the request through
+	repo.err = pkg.ErrDatabase
+	if err := s.CheckAccess("shopper", entities.RoleUser, ""); !errors.Is(err, pkg.ErrDatabase) {
+		t.Errorf("CheckAccess with the database down = %v, want ErrDatabase", err)
+	}
+}
+
+// TestRepoLookupErrors runs against the database in TEST_DATABASE_URL.
+func TestRepoLookupErrors(t *testing.T) {
+	db := testDB(t)
+	r := NewRepo(db)
+	if _, err := r.FindByUUID("lookup-test-missing"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("FindByUUID of a missing user = %v, want ErrNotFound", err)
+	}
+
+	// A closed connection stands in for a database that is down
+	closed := testDB(t)
+	closed.Close()
+	r = NewRepo(closed)
+	if _, err := r.FindByUUID("x"); !errors.Is(err, pkg.ErrDatabase) {
+		t.Errorf("FindByUUID = %v, want ErrDatabase", err)
+	}
+	if _, err := r.FindByEmail("x@example.com"); !errors.Is(err, pkg.ErrDatabase) {
+		t.Errorf("FindByEmail = %v, want ErrDatabase", err)
+	}
+	if _, err := r.FindByID(1); !errors.Is(err, pkg.ErrDatabase) {
+		t.Errorf("FindByID = %v, want ErrDatabase", err)
+	}
+	if _, err := r.DoesEmailExist("x@example.com"); !errors.Is(err, pkg.ErrDatabase) {
+		t.Errorf("DoesEmailExist = %v, want ErrDatabase", err)
+	}
+}
@@ -0,0 +1,144 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func registered(t *testing.T, s *service) *entities.User {
+	t.Helper()
+	u, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1", PhoneNumber: "+447911123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestDeleteAccount(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo, &sentSMS{})
+	u := registered(t, s)
+
+	// Leftover counters of the account
+	if _, err := s.attempts.AddFailure(totpKey(u.UUID), time.Now(), s.policy.Window); err != nil {
+		t.Fatal(err)
+	}
+
+	var removed string
+	err := s.DeleteAccount(u.UUID, "secret1", "10.0.0.1", func(userID string) error {
+		removed = userID
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != u.UUID {
+		t.Errorf("removeData called with %q, want %q", removed, u.UUID)
+	}
+	if _, err := repo.FindByUUID(u.UUID); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("account still found: %v", err)
+	}
+	if _, err := repo.FindByEmail("ann@example.com"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("email still identifies the account: %v", err)
+	}
+	if _, err := repo.FindByPhoneNumber("+447911123456"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("phone number still identifies the account: %v", err)
+	}
+	for _, key := range []string{accountKey("ann@example.com"), totpKey(u.UUID)} {
+		if a, _ := s.attempts.Get(key); a.Failures != 0 {
+			t.Errorf("counter %s kept after deletion", key)
+		}
+	}
+
+	// The address is free again
+	if _, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("registering the email again: %v", err)
+	}
+}
+
+func TestDeleteAccountWrongPassword(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo, &sentSMS{})
+	u := registered(t, s)
+
+	called := false
+	err := s.DeleteAccount(u.UUID, "wrong", "10.0.0.1", func(string) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, pkg.ErrWrongPassword) {
+		t.Fatalf("DeleteAccount = %v, want ErrWrongPassword", err)
+	}
+	if called {
+		t.Error("data removed without the password")
+	}
+	if _, err := repo.FindByUUID(u.UUID); err != nil {
+		t.Errorf("account gone: %v", err)
+	}
+	if a, _ := s.attempts.Get(accountKey(u.Email)); a.Failures != 1 {
+		t.Errorf("wrong password counted %d times, want once", a.Failures)
+	}
+}
+
+func TestDeleteAccountKeepsAccountWhenDataRemains(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo, &sentSMS{})
+	u := registered(t, s)
+
+	failed := errors.New("carts not deleted")
+	err := s.DeleteAccount(u.UUID, "secret1", "10.0.0.1", func(string) error { return failed })
+	if !errors.Is(err, failed) {
+		t.Fatalf("DeleteAccount = %v, want the error of removeData", err)
+	}
+	got, err := repo.FindByUUID(u.UUID)
+	if err != nil || got.Email != "ann@example.com" {
+		t.Errorf("account changed although its data remains: %+v, %v", got, err)
+	}
+}
+
+// TestAnonymise runs against the database in TEST_DATABASE_URL.
+func TestAnonymise(t *testing.T) {
+	db := testDB(t)
+	r := NewRepo(db)
+	attempts := NewPostgresAttemptStore(db)
+	u, err := r.Register(&entities.User{UUID: "anonymise-test", Name: "Ann", Email: "anonymise-test@example.com", PhoneNumber: "+447911000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Unscoped().Delete(u)
+	if _, err := r.CreatePhoneOTP(&entities.PhoneOTP{PhoneNumber: u.PhoneNumber}); err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{accountKey(u.Email), totpKey(u.UUID)}
+	for _, key := range keys {
+		if _, err := attempts.AddFailure(key, time.Now(), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Anonymise(u); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FindByUUID(u.UUID); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("account still found: %v", err)
+	}
+	var stored entities.User
+	if err := db.Unscoped().Where("uuid = ?", u.UUID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "" || stored.Password != "" || stored.PhoneNumber != "" || stored.Email == "anonymise-test@example.com" {
+		t.Errorf("personal data left: %+v", stored)
+	}
+	if _, err := r.FindLatestPhoneOTP("+447911000001"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("phone codes left: %v", err)
+	}
+	for _, key := range keys {
+		if a, _ := attempts.Get(key); a.Failures != 0 {
+			t.Errorf("counter %s left", key)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// EmailSender delivers emails to a single address.
+type EmailSender interface {
+	Send(to, subject, body string) error
+}
+
+type logEmailSender struct{}
+
+// NewLogEmailSender returns an EmailSender that only writes the email to
+// the log, meant for local runs where no mail provider is configured.
+func NewLogEmailSender() EmailSender {
+	return &logEmailSender{}
+}
+
+func (s *logEmailSender) Send(to, subject, body string) error {
+	log.Printf("Email to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+type smtpEmailSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPEmailSender returns an EmailSender that sends through an SMTP
+// server. The server is only logged in to when username is set, and then
+// only over TLS.
+func NewSMTPEmailSender(host, port, username, password, from string) EmailSender {
+	s := &smtpEmailSender{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *smtpEmailSender) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("smtp: header values must not contain line breaks")
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", s.from, to, subject, body)
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, []byte(msg))
+}
@@ -0,0 +1,215 @@
+package user
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"sync"
+	"time"
+)
+
+// AttemptStore persists failed login counters. Get never returns
+// pkg.ErrNotFound, a key without failures yields an empty attempt.
+type AttemptStore interface {
+	Get(key string) (*entities.LoginAttempt, error)
+
+	// AddFailure counts a failure of the key at now and returns the updated
+	// counter. Counting starts over when the last failure was more than
+	// window ago and the key is not locked. Concurrent failures are all
+	// counted.
+	AddFailure(key string, now time.Time, window time.Duration) (*entities.LoginAttempt, error)
+
+	// Lock locks the key until the given time, unless it is locked for
+	// longer already.
+	Lock(key string, until time.Time) error
+
+	Delete(key string) error
+}
+
+// LockoutPolicy decides when failed logins lead to a temporary lockout.
+// Once the threshold for a key is reached every further failure doubles
+// the lockout, starting at BaseLockout and capped at MaxLockout. Counters
+// are forgotten after Window without failures.
+type LockoutPolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+	Window             time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      20,
+	BaseLockout:        time.Minute,
+	MaxLockout:         time.Hour,
+	Window:             15 * time.Minute,
+}
+
+func accountKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// totpKey counts the failed second factor codes of a user.
+func totpKey(userUUID string) string {
+	return "totp:" + userUUID
+}
+
+// lockedFor returns how long the key is still locked, or zero.
+func lockedFor(a *entities.LoginAttempt, now time.Time) time.Duration {
+	if a.LockedUntil.After(now) {
+		return a.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// lockout returns how long a key that failed failures times is locked
+// for, zero while it is below max.
+func (p LockoutPolicy) lockout(failures, max int) time.Duration {
+	if failures < max {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := max; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+	return lockout
+}
+
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]entities.LoginAttempt
+}
+
+// NewMemoryAttemptStore keeps counters in process memory. It is only
+// suitable when a single instance of the server is running.
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{
+		attempts: make(map[string]entities.LoginAttempt),
+	}
+}
+
+func (s *memoryAttemptStore) Get(key string) (*entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok {
+		return &entities.LoginAttempt{Key: key}, nil
+	}
+	return &a, nil
+}
+
+func (s *memoryAttemptStore) AddFailure(key string, now time.Time, window time.Duration) (*entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.attempts[key]
+	a.Key = key
+	if now.Sub(a.LastFailureAt) > window && !a.LockedUntil.After(now) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	s.attempts[key] = a
+
+	// Sweep stale entries now and then so that sprayed keys do not pile up.
+	if len(s.attempts)%1000 == 0 {
+		cutoff := now.Add(-DefaultLockoutPolicy.MaxLockout)
+		for k, a := range s.attempts {
+			if a.LastFailureAt.Before(cutoff) && a.LockedUntil.Before(now) {
+				delete(s.attempts, k)
+			}
+		}
+	}
+	return &a, nil
+}
+
+func (s *memoryAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if ok && a.LockedUntil.Before(until) {
+		a.LockedUntil = until
+		s.attempts[key] = a
+	}
+	return nil
+}
+
+func (s *memoryAttemptStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+type postgresAttemptStore struct {
+	DB *gorm.DB
+}
+
+// NewPostgresAttemptStore keeps counters in the login_attempts table so
+// they are shared between instances.
+func NewPostgresAttemptStore(db *gorm.DB) AttemptStore {
+	return &postgresAttemptStore{
+		DB: db,
+	}
+}
+
+func (s *postgresAttemptStore) Get(key string) (*entities.LoginAttempt, error) {
+	attempt := &entities.LoginAttempt{}
+	result := s.DB.Where("key = ?", key).First(attempt)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return &entities.LoginAttempt{Key: key}, nil
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return attempt, nil
+}
+
+// addFailure counts the failure in a single statement, so that concurrent
+// failures neither get lost nor collide on the unique key.
+const addFailure = `INSERT INTO login_attempts (created_at, updated_at, "key", failures, last_failure_at, locked_until)
+VALUES (?, ?, ?, 1, ?, ?)
+ON CONFLICT ("key") DO UPDATE SET
+	failures = CASE
+		WHEN login_attempts.last_failure_at < ? AND COALESCE(login_attempts.locked_until, ?) <= ? THEN 1
+		ELSE login_attempts.failures + 1
+	END,
+	last_failure_at = EXCLUDED.last_failure_at,
+	updated_at = EXCLUDED.updated_at
+RETURNING *`
+
+func (s *postgresAttemptStore) AddFailure(key string, now time.Time, window time.Duration) (*entities.LoginAttempt, error) {
+	attempt := &entities.LoginAttempt{}
+	err := s.DB.Raw(addFailure, now, now, key, now, time.Time{}, now.Add(-window), time.Time{}, now).Scan(attempt).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return attempt, nil
+}
+
+func (s *postgresAttemptStore) Lock(key string, until time.Time) error {
+	err := s.DB.Exec(
+		`UPDATE login_attempts SET locked_until = ?, updated_at = ? WHERE "key" = ? AND (locked_until IS NULL OR locked_until < ?)`,
+		until, time.Now(), key, until,
+	).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (s *postgresAttemptStore) Delete(key string) error {
+	err := s.DB.Unscoped().Where("key = ?", key).Delete(&entities.LoginAttempt{}).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
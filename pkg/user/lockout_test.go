@@ -0,0 +1,171 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func TestLockoutPolicy(t *testing.T) {
+	p := LockoutPolicy{BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.lockout(tt.failures, 5); got != tt.want {
+			t.Errorf("lockout(%d, 5) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// testAttemptStore checks the behaviour every AttemptStore shares.
+func testAttemptStore(t *testing.T, store AttemptStore, key string) {
+	now := time.Now().Truncate(time.Second)
+	window := 15 * time.Minute
+
+	a, err := store.Get(key)
+	if err != nil || a.Key != key || a.Failures != 0 {
+		t.Fatalf("Get of a new key = %+v, %v", a, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		a, err := store.AddFailure(key, now.Add(time.Duration(i)*time.Minute), window)
+		if err != nil || a.Failures != i {
+			t.Fatalf("failure %d counted as %+v, %v", i, a, err)
+		}
+	}
+
+	// Counting starts over once the window has passed
+	later := now.Add(time.Hour)
+	a, err = store.AddFailure(key, later, window)
+	if err != nil || a.Failures != 1 {
+		t.Fatalf("failure after the window = %+v, %v, want 1", a, err)
+	}
+
+	// but not while the key is locked
+	if err := store.Lock(key, later.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Lock(key, later.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	a, err = store.AddFailure(key, later.Add(time.Hour), window)
+	if err != nil || a.Failures != 2 {
+		t.Fatalf("failure while locked = %+v, %v, want 2", a, err)
+	}
+	a, err = store.Get(key)
+	if err != nil || !a.LockedUntil.Equal(later.Add(2*time.Hour)) {
+		t.Fatalf("shorter lock replaced the longer one: %+v, %v", a, err)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	a, err = store.Get(key)
+	if err != nil || a.Failures != 0 || !a.LockedUntil.IsZero() {
+		t.Fatalf("Get after Delete = %+v, %v", a, err)
+	}
+
+	// Concurrent failures are all counted
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.AddFailure(key, now, window); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	a, err = store.Get(key)
+	if err != nil || a.Failures != 20 {
+		t.Fatalf("20 concurrent failures counted as %+v, %v", a, err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryAttemptStore(t *testing.T) {
+	testAttemptStore(t, NewMemoryAttemptStore(), "account:ann@example.com")
+}
+
+// TestPostgresAttemptStore runs against the database in TEST_DATABASE_URL.
+func TestPostgresAttemptStore(t *testing.T) {
+	db := testDB(t)
+	testAttemptStore(t, NewPostgresAttemptStore(db), "account:lockout-test@example.com")
+}
+
+func TestLoginLocksOut(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	if _, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < s.policy.MaxAccountFailures; i++ {
+		// The account is counted regardless of case and client
+		ip := fmt.Sprintf("10.0.0.%d", i+1)
+		if _, _, err := s.Login("Ann@Example.com", "wrong", ip); !errors.Is(err, pkg.ErrNotFound) {
+			t.Fatalf("attempt %d: %v, want ErrNotFound", i+1, err)
+		}
+	}
+	var retry *pkg.RetryError
+	_, _, err := s.Login("ann@example.com", "secret1", "10.0.0.9")
+	if !errors.As(err, &retry) || !errors.Is(err, pkg.ErrLocked) || retry.RetryAfter <= 0 || retry.RetryAfter > s.policy.BaseLockout {
+		t.Fatalf("right password while locked: %v, want ErrLocked for up to %s", err, s.policy.BaseLockout)
+	}
+}
+
+func TestLoginLocksOutClientIP(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	s.policy.MaxIPFailures = 3
+	if _, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spraying unknown accounts from one address
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if _, _, err := s.Login(email, "wrong", "10.0.0.1"); !errors.Is(err, pkg.ErrNotFound) {
+			t.Fatalf("%s: %v, want ErrNotFound", email, err)
+		}
+	}
+	if _, _, err := s.Login("ann@example.com", "secret1", "10.0.0.1"); !errors.Is(err, pkg.ErrLocked) {
+		t.Errorf("locked address: %v, want ErrLocked", err)
+	}
+	if u, _, err := s.Login("ann@example.com", "secret1", "10.0.0.2"); err != nil || u == nil {
+		t.Errorf("other address: %v, %v", u, err)
+	}
+}
+
+func TestLoginSuccessResetsAccount(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	if _, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+	for round := 0; round < 2; round++ {
+		for i := 0; i < s.policy.MaxAccountFailures-1; i++ {
+			if _, _, err := s.Login("ann@example.com", "wrong", "10.0.0.1"); !errors.Is(err, pkg.ErrNotFound) {
+				t.Fatalf("round %d attempt %d: %v", round, i+1, err)
+			}
+		}
+		if u, _, err := s.Login("ann@example.com", "secret1", "10.0.0.1"); err != nil || u == nil {
+			t.Fatalf("round %d: %v, %v", round, u, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"+44 7911 123456", "+447911123456"},
+		{"07911 123456", "+447911123456"},
+		{"447911123456", "+447911123456"},
+		{"(07911) 123-456", "+447911123456"},
+		{"+91 98765 43210", "+919876543210"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePhoneNumber(tt.in, "GB")
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizePhoneNumber(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "12", "not a number", "+44 7911"} {
+		if _, err := NormalizePhoneNumber(in, "GB"); !errors.Is(err, pkg.ErrPhoneNumber) {
+			t.Errorf("NormalizePhoneNumber(%q) = %v, want ErrPhoneNumber", in, err)
+		}
+	}
+}
+
+// registerWithPhone creates an account with the number and returns the
+// service and the SMS it sends.
+func registerWithPhone(t *testing.T, phoneNumber string) (*service, *memRepo, *sentSMS) {
+	t.Helper()
+	repo := newMemRepo()
+	sms := &sentSMS{}
+	s := newTestService(repo, sms)
+	_, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1", PhoneNumber: phoneNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, repo, sms
+}
+
+func TestRegisterStoresE164(t *testing.T) {
+	s, repo, _ := registerWithPhone(t, "07911 123456")
+	u, err := repo.FindByPhoneNumber("+447911123456")
+	if err != nil {
+		t.Fatalf("account not found by its E.164 number: %v", err)
+	}
+	if u.PhoneNumber != "+447911123456" {
+		t.Errorf("stored %q", u.PhoneNumber)
+	}
+
+	_, err = s.Register(&entities.User{Name: "Bob", Email: "bob@example.com", Password: "secret1", PhoneNumber: "+44 7911 123456"})
+	if !errors.Is(err, pkg.ErrExists) {
+		t.Errorf("same number written differently: %v, want ErrExists", err)
+	}
+}
+
+func TestRequestPhoneOTPResendDelayAcrossFormats(t *testing.T) {
+	s, _, sms := registerWithPhone(t, "+447911123456")
+	if err := s.RequestPhoneOTP("07911 123456"); err != nil {
+		t.Fatal(err)
+	}
+	if sms.last("+447911123456") == "" {
+		t.Fatal("no code sent to the E.164 number")
+	}
+	for _, again := range []string{"+447911123456", "447911123456", "+44 7911 123 456"} {
+		if err := s.RequestPhoneOTP(again); !errors.Is(err, pkg.ErrTooManyRequests) {
+			t.Errorf("RequestPhoneOTP(%q) right after a code = %v, want ErrTooManyRequests", again, err)
+		}
+	}
+	if err := s.RequestPhoneOTP("12"); !errors.Is(err, pkg.ErrPhoneNumber) {
+		t.Errorf("invalid number: %v, want ErrPhoneNumber", err)
+	}
+}
+
+func TestRequestPhoneOTPUnknownNumber(t *testing.T) {
+	s, repo, sms := registerWithPhone(t, "+447911123456")
+	if err := s.RequestPhoneOTP("+447911123999"); err != nil {
+		t.Fatalf("unknown numbers get the same answer: %v", err)
+	}
+	if sms.last("+447911123999") != "" {
+		t.Error("code sent to a number without an account")
+	}
+	if _, err := repo.FindLatestPhoneOTP("+447911123999"); err != nil {
+		t.Errorf("unknown numbers are rate limited too: %v", err)
+	}
+}
+
+func TestVerifyPhoneOTP(t *testing.T) {
+	s, _, sms := registerWithPhone(t, "+447911123456")
+	if err := s.RequestPhoneOTP("+447911123456"); err != nil {
+		t.Fatal(err)
+	}
+	u, _, err := s.VerifyPhoneOTP("07911 123456", sms.last("+447911123456"))
+	if err != nil || u == nil || u.Email != "ann@example.com" {
+		t.Fatalf("VerifyPhoneOTP = %v, %v", u, err)
+	}
+	if _, _, err := s.VerifyPhoneOTP("+447911123456", sms.last("+447911123456")); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("code used twice: %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestVerifyPhoneOTPAttemptLimit(t *testing.T) {
+	s, _, sms := registerWithPhone(t, "+447911123456")
+	if err := s.RequestPhoneOTP("+447911123456"); err != nil {
+		t.Fatal(err)
+	}
+	code := sms.last("+447911123456")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < otpMaxAttempts; i++ {
+		if _, _, err := s.VerifyPhoneOTP("+447911123456", wrong); !errors.Is(err, pkg.ErrInvalidCode) {
+			t.Fatalf("attempt %d: %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	if _, _, err := s.VerifyPhoneOTP("+447911123456", code); !errors.Is(err, pkg.ErrTooManyRequests) {
+		t.Errorf("right code after %d failures: %v, want ErrTooManyRequests", otpMaxAttempts, err)
+	}
+}
+
+func TestVerifyPhoneOTPExpired(t *testing.T) {
+	s, repo, sms := registerWithPhone(t, "+447911123456")
+	if err := s.RequestPhoneOTP("+447911123456"); err != nil {
+		t.Fatal(err)
+	}
+	otp, err := repo.FindLatestPhoneOTP("+447911123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otp.ExpiresAt = time.Now().Add(-time.Second)
+	if err := repo.SavePhoneOTP(otp); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.VerifyPhoneOTP("+447911123456", sms.last("+447911123456")); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("expired code: %v, want ErrInvalidCode", err)
+	}
+}
@@ -1,7 +1,10 @@
 package user
 
 import (
+	"errors"
+
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/rithikjain/quickscan-backend/pkg"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
 )
@@ -16,6 +19,43 @@ type Repository interface {
 	Register(user *entities.User) (*entities.User, error)
 
 	DoesEmailExist(email string) (bool, error)
+
+	FindByPhoneNumber(phoneNumber string) (*entities.User, error)
+
+	CreatePhoneOTP(otp *entities.PhoneOTP) (*entities.PhoneOTP, error)
+
+	FindLatestPhoneOTP(phoneNumber string) (*entities.PhoneOTP, error)
+
+	SavePhoneOTP(otp *entities.PhoneOTP) error
+
+	SaveUser(user *entities.User) error
+
+	// AdvanceTOTPStep records step as the last TOTP step used by the user.
+	// It reports false when that step or a later one was recorded already,
+	// which happens when the same code is used twice at once.
+	AdvanceTOTPStep(userID string, step int64) (bool, error)
+
+	ReplaceRecoveryCodes(userID string, codes []entities.RecoveryCode) error
+
+	GetRecoveryCodes(userID string) (*[]entities.RecoveryCode, error)
+
+	SaveRecoveryCode(code *entities.RecoveryCode) error
+
+	CreateLoginChallenge(challenge *entities.LoginChallenge) (*entities.LoginChallenge, error)
+
+	FindLoginChallenge(tokenHash string) (*entities.LoginChallenge, error)
+
+	SaveLoginChallenge(challenge *entities.LoginChallenge) error
+
+	CreateEmailChange(change *entities.EmailChange) (*entities.EmailChange, error)
+
+	FindEmailChange(tokenHash string) (*entities.EmailChange, error)
+
+	SaveEmailChange(change *entities.EmailChange) error
+
+	// Anonymise scrubs all personal data from the user, removes the
+	// records tied to them and soft deletes the row.
+	Anonymise(user *entities.User) error
 }
 
 type repo struct {
@@ -30,36 +70,64 @@ func NewRepo(db *gorm.DB) Repository {
 
 func (r *repo) FindByID(id float64) (*entities.User, error) {
 	user := &entities.User{}
-	r.DB.Where("id = ?", id).First(user)
-	if user.Email == "" {
+	result := r.DB.Where("id = ?", id).First(user)
+
+	if result.Error == gorm.ErrRecordNotFound {
 		return nil, pkg.ErrNotFound
 	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
 	return user, nil
 }
 
+// uniqueViolation is the Postgres error code of a duplicate key.
+const uniqueViolation = "23505"
+
+// writeError maps an error of an insert or update. A duplicate email or
+// phone number that slipped past the checks is caught by the unique
+// indexes and reported as pkg.ErrExists.
+func writeError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return pkg.ErrExists
+	}
+	return pkg.ErrDatabase
+}
+
 func (r *repo) Register(user *entities.User) (*entities.User, error) {
 	result := r.DB.Create(user)
 	if result.Error != nil {
-		return nil, pkg.ErrDatabase
+		return nil, writeError(result.Error)
 	}
 	return user, nil
 }
 
+// Emails are matched regardless of case, like the unique index on them.
+
 func (r *repo) DoesEmailExist(email string) (bool, error) {
 	user := &entities.User{}
-	if r.DB.Where("email = ?", email).First(user).RecordNotFound() {
+	result := r.DB.Where("lower(email) = lower(?)", email).First(user)
+
+	if result.Error == gorm.ErrRecordNotFound {
 		return false, nil
 	}
+	if result.Error != nil {
+		return false, pkg.ErrDatabase
+	}
 	return true, nil
 }
 
 func (r *repo) FindByEmail(email string) (*entities.User, error) {
 	user := &entities.User{}
-	result := r.DB.Where("email = ?", email).First(user)
+	result := r.DB.Where("lower(email) = lower(?)", email).First(user)
 
 	if result.Error == gorm.ErrRecordNotFound {
 		return nil, pkg.ErrNotFound
 	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
 	return user, nil
 }
 
@@ -70,5 +138,208 @@ func (r *repo) FindByUUID(uuid string) (*entities.User, error) {
 	if result.Error == gorm.ErrRecordNotFound {
 		return nil, pkg.ErrNotFound
 	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return user, nil
+}
+
+func (r *repo) FindByPhoneNumber(phoneNumber string) (*entities.User, error) {
+	// Accounts without a phone number all have it empty
+	if phoneNumber == "" {
+		return nil, pkg.ErrNotFound
+	}
+	user := &entities.User{}
+	result := r.DB.Where("phone_number = ?", phoneNumber).First(user)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
 	return user, nil
 }
+
+func (r *repo) CreatePhoneOTP(otp *entities.PhoneOTP) (*entities.PhoneOTP, error) {
+	result := r.DB.Create(otp)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return otp, nil
+}
+
+func (r *repo) FindLatestPhoneOTP(phoneNumber string) (*entities.PhoneOTP, error) {
+	otp := &entities.PhoneOTP{}
+	result := r.DB.Where("phone_number = ?", phoneNumber).Order("created_at desc").First(otp)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return otp, nil
+}
+
+func (r *repo) SavePhoneOTP(otp *entities.PhoneOTP) error {
+	err := r.DB.Save(otp).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) SaveUser(user *entities.User) error {
+	err := r.DB.Save(user).Error
+	if err != nil {
+		return writeError(err)
+	}
+	return nil
+}
+
+func (r *repo) AdvanceTOTPStep(userID string, step int64) (bool, error) {
+	result := r.DB.Model(&entities.User{}).
+		Where("uuid = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, pkg.ErrDatabase
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repo) ReplaceRecoveryCodes(userID string, codes []entities.RecoveryCode) error {
+	tx := r.DB.Begin()
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	for i := range codes {
+		codes[i].UserID = userID
+		if err := tx.Create(&codes[i]).Error; err != nil {
+			tx.Rollback()
+			return pkg.ErrDatabase
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) GetRecoveryCodes(userID string) (*[]entities.RecoveryCode, error) {
+	var codes []entities.RecoveryCode
+	err := r.DB.Where("user_id = ? AND used = ?", userID, false).Find(&codes).Error
+	if err != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return &codes, nil
+}
+
+func (r *repo) SaveRecoveryCode(code *entities.RecoveryCode) error {
+	err := r.DB.Save(code).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) CreateLoginChallenge(challenge *entities.LoginChallenge) (*entities.LoginChallenge, error) {
+	result := r.DB.Create(challenge)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return challenge, nil
+}
+
+func (r *repo) FindLoginChallenge(tokenHash string) (*entities.LoginChallenge, error) {
+	challenge := &entities.LoginChallenge{}
+	result := r.DB.Where("token_hash = ?", tokenHash).First(challenge)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return challenge, nil
+}
+
+func (r *repo) SaveLoginChallenge(challenge *entities.LoginChallenge) error {
+	err := r.DB.Save(challenge).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) CreateEmailChange(change *entities.EmailChange) (*entities.EmailChange, error) {
+	result := r.DB.Create(change)
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return change, nil
+}
+
+func (r *repo) FindEmailChange(tokenHash string) (*entities.EmailChange, error) {
+	change := &entities.EmailChange{}
+	result := r.DB.Where("token_hash = ?", tokenHash).First(change)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil, pkg.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, pkg.ErrDatabase
+	}
+	return change, nil
+}
+
+func (r *repo) SaveEmailChange(change *entities.EmailChange) error {
+	err := r.DB.Save(change).Error
+	if err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
+
+func (r *repo) Anonymise(user *entities.User) error {
+	tx := r.DB.Begin()
+	cleanup := []struct {
+		query string
+		arg   interface{}
+		model interface{}
+	}{
+		{"user_id = ?", user.UUID, &entities.RecoveryCode{}},
+		{"user_id = ?", user.UUID, &entities.LoginChallenge{}},
+		{"user_id = ?", user.UUID, &entities.EmailChange{}},
+		{"phone_number = ?", user.PhoneNumber, &entities.PhoneOTP{}},
+		// Failed logins are counted under the email
+		{`"key" IN (?)`, []string{accountKey(user.Email), totpKey(user.UUID)}, &entities.LoginAttempt{}},
+	}
+	for _, c := range cleanup {
+		if err := tx.Unscoped().Where(c.query, c.arg).Delete(c.model).Error; err != nil {
+			tx.Rollback()
+			return pkg.ErrDatabase
+		}
+	}
+
+	user.Name = ""
+	user.Email = "deleted-" + user.UUID + "@deleted.invalid"
+	user.Password = ""
+	user.ImageUrl = ""
+	user.PhoneNumber = ""
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	if err := tx.Save(user).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Delete(user).Error; err != nil {
+		tx.Rollback()
+		return pkg.ErrDatabase
+	}
+	if err := tx.Commit().Error; err != nil {
+		return pkg.ErrDatabase
+	}
+	return nil
+}
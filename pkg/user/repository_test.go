@@ -0,0 +1,337 @@
+package user
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/audit"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"github.com/rithikjain/quickscan-backend/pkg/migrate"
+)
+
+// testDB connects to the database in TEST_DATABASE_URL and migrates it. The
+// test is skipped without one.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := migrate.New(db.DB(), migrate.All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// memRepo is a Repository kept in memory for the service tests. Like the
+// database it hands out copies, changes only stick once they are saved.
+type memRepo struct {
+	mu         sync.Mutex
+	nextID     uint
+	users      []entities.User
+	otps       []entities.PhoneOTP
+	codes      []entities.RecoveryCode
+	challenges []entities.LoginChallenge
+	changes    []entities.EmailChange
+	// err is returned by every lookup when set, to stand in for a database
+	// that is down
+	err error
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{}
+}
+
+func (r *memRepo) id() uint {
+	r.nextID++
+	return r.nextID
+}
+
+func (r *memRepo) findUser(match func(u *entities.User) bool) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i := range r.users {
+		if r.users[i].DeletedAt == nil && match(&r.users[i]) {
+			u := r.users[i]
+			return &u, nil
+		}
+	}
+	return nil, pkg.ErrNotFound
+}
+
+func (r *memRepo) FindByID(id float64) (*entities.User, error) {
+	return r.findUser(func(u *entities.User) bool { return float64(u.ID) == id })
+}
+
+func (r *memRepo) FindByEmail(email string) (*entities.User, error) {
+	return r.findUser(func(u *entities.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (r *memRepo) FindByUUID(uuid string) (*entities.User, error) {
+	return r.findUser(func(u *entities.User) bool { return u.UUID == uuid })
+}
+
+func (r *memRepo) Register(user *entities.User) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, user.Email) || (user.PhoneNumber != "" && u.PhoneNumber == user.PhoneNumber) {
+			return nil, pkg.ErrExists
+		}
+	}
+	user.ID = r.id()
+	user.CreatedAt = time.Now()
+	r.users = append(r.users, *user)
+	return user, nil
+}
+
+func (r *memRepo) DoesEmailExist(email string) (bool, error) {
+	_, err := r.FindByEmail(email)
+	if err == pkg.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memRepo) FindByPhoneNumber(phoneNumber string) (*entities.User, error) {
+	if phoneNumber == "" {
+		return nil, pkg.ErrNotFound
+	}
+	return r.findUser(func(u *entities.User) bool { return u.PhoneNumber == phoneNumber })
+}
+
+func (r *memRepo) CreatePhoneOTP(otp *entities.PhoneOTP) (*entities.PhoneOTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	otp.ID = r.id()
+	otp.CreatedAt = time.Now()
+	r.otps = append(r.otps, *otp)
+	return otp, nil
+}
+
+func (r *memRepo) FindLatestPhoneOTP(phoneNumber string) (*entities.PhoneOTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i := len(r.otps) - 1; i >= 0; i-- {
+		if r.otps[i].PhoneNumber == phoneNumber {
+			otp := r.otps[i]
+			return &otp, nil
+		}
+	}
+	return nil, pkg.ErrNotFound
+}
+
+func (r *memRepo) SavePhoneOTP(otp *entities.PhoneOTP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.otps {
+		if r.otps[i].ID == otp.ID {
+			r.otps[i] = *otp
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) SaveUser(user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			r.users[i] = *user
+			return nil
+		}
+	}
+	return pkg.ErrNotFound
+}
+
+func (r *memRepo) AdvanceTOTPStep(userID string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].UUID == userID && r.users[i].TOTPLastStep < step {
+			r.users[i].TOTPLastStep = step
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memRepo) ReplaceRecoveryCodes(userID string, codes []entities.RecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	r.codes = kept
+	for i := range codes {
+		codes[i].ID = r.id()
+		codes[i].UserID = userID
+		r.codes = append(r.codes, codes[i])
+	}
+	return nil
+}
+
+func (r *memRepo) GetRecoveryCodes(userID string) (*[]entities.RecoveryCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var codes []entities.RecoveryCode
+	for _, c := range r.codes {
+		if c.UserID == userID && !c.Used {
+			codes = append(codes, c)
+		}
+	}
+	return &codes, nil
+}
+
+func (r *memRepo) SaveRecoveryCode(code *entities.RecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.codes {
+		if r.codes[i].ID == code.ID {
+			r.codes[i] = *code
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) CreateLoginChallenge(challenge *entities.LoginChallenge) (*entities.LoginChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge.ID = r.id()
+	r.challenges = append(r.challenges, *challenge)
+	return challenge, nil
+}
+
+func (r *memRepo) FindLoginChallenge(tokenHash string) (*entities.LoginChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.challenges {
+		if c.TokenHash == tokenHash {
+			return &c, nil
+		}
+	}
+	return nil, pkg.ErrNotFound
+}
+
+func (r *memRepo) SaveLoginChallenge(challenge *entities.LoginChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.challenges {
+		if r.challenges[i].ID == challenge.ID {
+			r.challenges[i] = *challenge
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) CreateEmailChange(change *entities.EmailChange) (*entities.EmailChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change.ID = r.id()
+	r.changes = append(r.changes, *change)
+	return change, nil
+}
+
+func (r *memRepo) FindEmailChange(tokenHash string) (*entities.EmailChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.changes {
+		if c.TokenHash == tokenHash {
+			return &c, nil
+		}
+	}
+	return nil, pkg.ErrNotFound
+}
+
+func (r *memRepo) SaveEmailChange(change *entities.EmailChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.changes {
+		if r.changes[i].ID == change.ID {
+			r.changes[i] = *change
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) Anonymise(user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			now := time.Now()
+			r.users[i] = entities.User{UUID: user.UUID, Email: "deleted-" + user.UUID + "@deleted.invalid"}
+			r.users[i].ID = user.ID
+			r.users[i].DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+// sentSMS records the messages instead of sending them.
+type sentSMS struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func (s *sentSMS) Send(phoneNumber, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages == nil {
+		s.messages = map[string][]string{}
+	}
+	s.messages[phoneNumber] = append(s.messages[phoneNumber], message)
+	return nil
+}
+
+// last returns the code of the last message sent to phoneNumber.
+func (s *sentSMS) last(phoneNumber string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := s.messages[phoneNumber]
+	if len(msgs) == 0 {
+		return ""
+	}
+	fields := strings.Fields(msgs[len(msgs)-1])
+	return fields[len(fields)-1]
+}
+
+type noAudit struct{}
+
+func (noAudit) Record(action, userID, ip, detail string) {}
+
+func (noAudit) GetUserEntries(userID string) (*[]entities.AuditEntry, error) {
+	return &[]entities.AuditEntry{}, nil
+}
+
+var _ audit.Service = noAudit{}
+
+// newTestService returns a service over repo that reads numbers without a
+// country code as British ones.
+func newTestService(repo Repository, sms SMSSender) *service {
+	return NewService(repo, sms, NewLogEmailSender(), NewMemoryAttemptStore(), noAudit{}, "GB").(*service)
+}
@@ -1,16 +1,56 @@
 package user
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	uuid2 "github.com/nu7hatch/gouuid"
+	"github.com/nyaruka/phonenumbers"
 	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/audit"
 	"github.com/rithikjain/quickscan-backend/pkg/entities"
 	"golang.org/x/crypto/bcrypt"
+	"math/big"
+	"net/url"
 	"strings"
+	"time"
 )
 
+const (
+	otpLength      = 6
+	otpTTL         = 5 * time.Minute
+	otpMaxAttempts = 5
+	otpResendDelay = 30 * time.Second
+
+	challengeTTL         = 5 * time.Minute
+	challengeMaxAttempts = 5
+	recoveryCodeCount    = 10
+
+	emailChangeTTL = 24 * time.Hour
+)
+
+// ProfileUpdate holds the profile fields a user can change. Nil fields are
+// left untouched.
+type ProfileUpdate struct {
+	Name        *string `json:"name"`
+	PhoneNumber *string `json:"phone_number"`
+	ImageUrl    *string `json:"image_url"`
+}
+
 type Service interface {
 	Register(user *entities.User) (*entities.User, error)
 
-	Login(email, password string) (*entities.User, error)
+	// RegisterWithRole creates the account with an elevated role in a single
+	// insert, for the command line. Staff work at storeID as for SetRole.
+	RegisterWithRole(user *entities.User, role, storeID string) (*entities.User, error)
+
+	// Login checks the credentials. For accounts with two factor
+	// authentication no user is returned, only a challenge token that has to
+	// be passed to CompleteTOTPLogin along with a valid code.
+	//
+	// Failed attempts are counted per account and per client IP, and
+	// repeated failures lock both out with an exponential backoff.
+	Login(email, password, ip string) (*entities.User, string, error)
 
 	GetUserByID(id float64) (*entities.User, error)
 
@@ -18,19 +58,85 @@ type Service interface {
 
 	GetUserByUUID(uuid string) (*entities.User, error)
 
+	RequestPhoneOTP(phoneNumber string) error
+
+	VerifyPhoneOTP(phoneNumber, code string) (*entities.User, string, error)
+
+	EnrollTOTP(userUUID string) (secret string, uri string, err error)
+
+	// ConfirmTOTP, DisableTOTP and CompleteTOTPLogin count wrong codes per
+	// user and lock the second factor out like Login does.
+	ConfirmTOTP(userUUID, code string) ([]string, error)
+
+	DisableTOTP(userUUID, code string) error
+
+	CompleteTOTPLogin(challengeToken, code string) (*entities.User, error)
+
+	UpdateProfile(userUUID string, update *ProfileUpdate) (*entities.User, error)
+
+	// RequestEmailChange mails a confirmation link to the new address, the
+	// email is only changed once ConfirmEmailChange is called with its token.
+	RequestEmailChange(userUUID, newEmail string) error
+
+	ConfirmEmailChange(token string) (*entities.User, error)
+
+	// ChangePassword checks the current password against the same lockout
+	// as Login before setting the new one.
+	ChangePassword(userUUID, currentPassword, newPassword, ip string) error
+
+	// DeleteAccount anonymises the user after checking their password, which
+	// counts towards the lockout of Login. removeData deletes what other
+	// services hold for the user and runs first, so that a failure leaves
+	// an account behind that the user can delete again, never orphaned data.
+	DeleteAccount(userUUID, password, ip string, removeData func(userID string) error) error
+
+	// SetRole changes the role of the user. Staff work at storeID, which is
+	// required for them and cleared for every other role.
+	SetRole(userUUID, role, storeID string) (*entities.User, error)
+
+	// SetDisabled blocks the account from logging in, or lets it log in
+	// again. Tokens issued before are rejected by CheckAccess from then on.
+	SetDisabled(userUUID string, disabled bool) (*entities.User, error)
+
+	// CheckAccess checks the claims of a token against the account on every
+	// request. It returns pkg.ErrDisabled for disabled accounts and
+	// pkg.ErrStaleToken once the account is gone or its role or store has
+	// changed since the token was issued.
+	CheckAccess(userUUID, role, storeID string) error
+
 	GetRepo() Repository
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	sms      SMSSender
+	emails   EmailSender
+	attempts AttemptStore
+	policy   LockoutPolicy
+	audit    audit.Service
+	// phoneRegion is the region of phone numbers given without a country
+	// code
+	phoneRegion string
 }
 
-func NewService(r Repository) Service {
+func NewService(r Repository, sms SMSSender, emails EmailSender, attempts AttemptStore, auditSvc audit.Service, phoneRegion string) Service {
 	return &service{
-		repo: r,
+		repo:        r,
+		sms:         sms,
+		emails:      emails,
+		attempts:    attempts,
+		policy:      DefaultLockoutPolicy,
+		audit:       auditSvc,
+		phoneRegion: phoneRegion,
 	}
 }
 
+// normalizeEmail trims and lowercases an email, accounts are identified by
+// the address regardless of case.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func Validate(user *entities.User) (bool, error) {
 	if !strings.Contains(user.Email, "@") {
 		return false, pkg.ErrEmail
@@ -42,7 +148,55 @@ func Validate(user *entities.User) (bool, error) {
 	return true, nil
 }
 
+// NormalizePhoneNumber returns the number in E.164 form, so that every way
+// of writing it identifies the same account. Numbers without a country code
+// are read as numbers of region. Invalid numbers give pkg.ErrPhoneNumber.
+func NormalizePhoneNumber(phoneNumber, region string) (string, error) {
+	num, err := phonenumbers.Parse(strings.TrimSpace(phoneNumber), region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return "", pkg.ErrPhoneNumber
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+func (s *service) validateProfile(update *ProfileUpdate) error {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" || len(name) > 100 {
+			return pkg.ErrName
+		}
+		update.Name = &name
+	}
+	if update.PhoneNumber != nil && *update.PhoneNumber != "" {
+		phoneNumber, err := NormalizePhoneNumber(*update.PhoneNumber, s.phoneRegion)
+		if err != nil {
+			return err
+		}
+		update.PhoneNumber = &phoneNumber
+	}
+	if update.ImageUrl != nil && *update.ImageUrl != "" {
+		u, err := url.Parse(*update.ImageUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return pkg.ErrImageUrl
+		}
+	}
+	return nil
+}
+
 func (s *service) Register(user *entities.User) (*entities.User, error) {
+	return s.register(user, entities.RoleUser, "")
+}
+
+func (s *service) RegisterWithRole(user *entities.User, role, storeID string) (*entities.User, error) {
+	storeID, err := roleStore(role, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.register(user, role, storeID)
+}
+
+func (s *service) register(user *entities.User, role, storeID string) (*entities.User, error) {
+	user.Email = normalizeEmail(user.Email)
 	// Validation
 	validate, err := Validate(user)
 	if !validate {
@@ -57,24 +211,156 @@ func (s *service) Register(user *entities.User) (*entities.User, error) {
 		//noinspection GoErrorStringFormat
 		return nil, pkg.ErrExists
 	}
+	// Phone numbers identify accounts for OTP login so they must be unique.
+	user.PhoneNumber = strings.TrimSpace(user.PhoneNumber)
+	if user.PhoneNumber != "" {
+		user.PhoneNumber, err = NormalizePhoneNumber(user.PhoneNumber, s.phoneRegion)
+		if err != nil {
+			return nil, err
+		}
+		_, err := s.repo.FindByPhoneNumber(user.PhoneNumber)
+		if err == nil {
+			return nil, pkg.ErrExists
+		}
+		if !errors.Is(err, pkg.ErrNotFound) {
+			return nil, err
+		}
+	}
 	pass, err := HashPassword(user.Password)
 	if err != nil {
 		return nil, err
 	}
 	user.Password = pass
+	if user.UUID == "" {
+		uuid, err := uuid2.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		user.UUID = uuid.String()
+	}
+	// Elevated roles are only ever granted through SetRole and
+	// RegisterWithRole.
+	user.Role = role
+	user.StoreID = storeID
 	return s.repo.Register(user)
 }
 
-func (s *service) Login(email, password string) (*entities.User, error) {
-	user := &entities.User{}
-	user, err := s.repo.FindByEmail(email)
+func (s *service) Login(email, password, ip string) (*entities.User, string, error) {
+	now := time.Now()
+	account, err := s.attempts.Get(accountKey(email))
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	client, err := s.attempts.Get(ipKey(ip))
+	if err != nil {
+		return nil, "", err
+	}
+	// Checked before bcrypt runs so that a locked out client costs no CPU.
+	wait := lockedFor(account, now)
+	if w := lockedFor(client, now); w > wait {
+		wait = w
+	}
+	if wait > 0 {
+		return nil, "", &pkg.RetryError{Err: pkg.ErrLocked, RetryAfter: wait}
+	}
+
+	user, err := s.repo.FindByEmail(normalizeEmail(email))
+	if err != nil && !errors.Is(err, pkg.ErrNotFound) {
+		return nil, "", err
+	}
+	if user != nil && CheckPasswordHash(password, user.Password) {
+		if err := s.attempts.Delete(account.Key); err != nil {
+			return nil, "", err
+		}
+		return s.secondFactor(user)
+	}
+
+	userID := ""
+	if user != nil {
+		userID = user.UUID
+	}
+	if err := s.fail(account.Key, s.policy.MaxAccountFailures, userID, ip, now); err != nil {
+		return nil, "", err
+	}
+	if err := s.fail(client.Key, s.policy.MaxIPFailures, userID, ip, now); err != nil {
+		return nil, "", err
+	}
+	return nil, "", pkg.ErrNotFound
+}
+
+// checkPassword confirms the password of a signed in user. Failures are
+// counted like failed logins, so a stolen token cannot be used to guess the
+// password.
+func (s *service) checkPassword(user *entities.User, password, ip string) error {
+	now := time.Now()
+	account, err := s.attempts.Get(accountKey(user.Email))
+	if err != nil {
+		return err
+	}
+	client, err := s.attempts.Get(ipKey(ip))
+	if err != nil {
+		return err
+	}
+	wait := lockedFor(account, now)
+	if w := lockedFor(client, now); w > wait {
+		wait = w
 	}
+	if wait > 0 {
+		return &pkg.RetryError{Err: pkg.ErrLocked, RetryAfter: wait}
+	}
+
 	if CheckPasswordHash(password, user.Password) {
-		return user, nil
+		return s.attempts.Delete(account.Key)
+	}
+	if err := s.fail(account.Key, s.policy.MaxAccountFailures, user.UUID, ip, now); err != nil {
+		return err
+	}
+	if err := s.fail(client.Key, s.policy.MaxIPFailures, user.UUID, ip, now); err != nil {
+		return err
+	}
+	return pkg.ErrWrongPassword
+}
+
+// fail counts a failed attempt of the key and locks it once it failed max
+// times, recording every lockout in the audit log.
+func (s *service) fail(key string, max int, userID, ip string, now time.Time) error {
+	attempt, err := s.attempts.AddFailure(key, now, s.policy.Window)
+	if err != nil {
+		return err
+	}
+	lockout := s.policy.lockout(attempt.Failures, max)
+	if lockout == 0 {
+		return nil
+	}
+	if err := s.attempts.Lock(key, now.Add(lockout)); err != nil {
+		return err
+	}
+	s.audit.Record(audit.ActionLoginLockout, userID, ip, key)
+	return nil
+}
+
+// secondFactor returns the user straight away when two factor
+// authentication is off, and a fresh login challenge otherwise.
+func (s *service) secondFactor(user *entities.User) (*entities.User, string, error) {
+	if user.DisabledAt != nil {
+		return nil, "", pkg.ErrDisabled
+	}
+	if !user.TOTPEnabled {
+		return user, "", nil
 	}
-	return nil, pkg.ErrNotFound
+	token, err := GenerateToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = s.repo.CreateLoginChallenge(&entities.LoginChallenge{
+		UserID:    user.UUID,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(challengeTTL),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return nil, token, nil
 }
 
 func (s *service) GetUserByID(id float64) (*entities.User, error) {
@@ -82,13 +368,516 @@ func (s *service) GetUserByID(id float64) (*entities.User, error) {
 }
 
 func (s *service) GetUserByEmail(email string) (*entities.User, error) {
-	return s.repo.FindByEmail(email)
+	return s.repo.FindByEmail(normalizeEmail(email))
 }
 
 func (s *service) GetUserByUUID(uuid string) (*entities.User, error) {
 	return s.repo.FindByUUID(uuid)
 }
 
+func (s *service) RequestPhoneOTP(phoneNumber string) error {
+	phoneNumber, err := NormalizePhoneNumber(phoneNumber, s.phoneRegion)
+	if err != nil {
+		return err
+	}
+	// Unknown numbers go through the same steps, only the SMS is not sent,
+	// so the answer does not tell whether the number has an account.
+	_, err = s.repo.FindByPhoneNumber(phoneNumber)
+	if err != nil && !errors.Is(err, pkg.ErrNotFound) {
+		return err
+	}
+	registered := err == nil
+
+	last, err := s.repo.FindLatestPhoneOTP(phoneNumber)
+	if err != nil && !errors.Is(err, pkg.ErrNotFound) {
+		return err
+	}
+	if last != nil && time.Since(last.CreatedAt) < otpResendDelay {
+		return pkg.ErrTooManyRequests
+	}
+
+	code, err := GenerateNumericCode(otpLength)
+	if err != nil {
+		return err
+	}
+	hash, err := HashPassword(code)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.CreatePhoneOTP(&entities.PhoneOTP{
+		PhoneNumber: phoneNumber,
+		CodeHash:    hash,
+		ExpiresAt:   time.Now().Add(otpTTL),
+	})
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return nil
+	}
+	return s.sms.Send(phoneNumber, fmt.Sprintf("Your QwikScan login code is %s", code))
+}
+
+func (s *service) VerifyPhoneOTP(phoneNumber, code string) (*entities.User, string, error) {
+	phoneNumber, err := NormalizePhoneNumber(phoneNumber, s.phoneRegion)
+	if err != nil {
+		return nil, "", pkg.ErrInvalidCode
+	}
+	otp, err := s.repo.FindLatestPhoneOTP(phoneNumber)
+	if errors.Is(err, pkg.ErrNotFound) {
+		return nil, "", pkg.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if otp.Consumed || time.Now().After(otp.ExpiresAt) {
+		return nil, "", pkg.ErrInvalidCode
+	}
+	if otp.Attempts >= otpMaxAttempts {
+		return nil, "", pkg.ErrTooManyRequests
+	}
+
+	if !CheckPasswordHash(code, otp.CodeHash) {
+		otp.Attempts++
+		if err := s.repo.SavePhoneOTP(otp); err != nil {
+			return nil, "", err
+		}
+		return nil, "", pkg.ErrInvalidCode
+	}
+
+	otp.Consumed = true
+	if err := s.repo.SavePhoneOTP(otp); err != nil {
+		return nil, "", err
+	}
+	user, err := s.repo.FindByPhoneNumber(phoneNumber)
+	if errors.Is(err, pkg.ErrNotFound) {
+		// Codes for unknown numbers are never sent, see RequestPhoneOTP
+		return nil, "", pkg.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return s.secondFactor(user)
+}
+
+func (s *service) EnrollTOTP(userUUID string) (string, string, error) {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", pkg.ErrTOTPEnabled
+	}
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	user.TOTPSecret = secret
+	if err := s.repo.SaveUser(user); err != nil {
+		return "", "", err
+	}
+	return secret, TOTPURI(user.Email, secret), nil
+}
+
+func (s *service) ConfirmTOTP(userUUID, code string) ([]string, error) {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, pkg.ErrTOTPEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, pkg.ErrTOTPNotEnrolled
+	}
+	ok, err := s.limitCode(user, func() (bool, error) { return s.useTOTP(user, code) })
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, pkg.ErrInvalidCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]entities.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		c, err := GenerateToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = c
+		records[i] = entities.RecoveryCode{CodeHash: HashToken(c)}
+	}
+	if err := s.repo.ReplaceRecoveryCodes(user.UUID, records); err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *service) DisableTOTP(userUUID, code string) error {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return pkg.ErrTOTPNotEnrolled
+	}
+	ok, err := s.limitCode(user, func() (bool, error) { return s.checkSecondFactor(user, code) })
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return pkg.ErrInvalidCode
+	}
+	if err := s.repo.ReplaceRecoveryCodes(user.UUID, nil); err != nil {
+		return err
+	}
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	return s.repo.SaveUser(user)
+}
+
+func (s *service) CompleteTOTPLogin(challengeToken, code string) (*entities.User, error) {
+	challenge, err := s.repo.FindLoginChallenge(HashToken(challengeToken))
+	if errors.Is(err, pkg.ErrNotFound) {
+		return nil, pkg.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Consumed || time.Now().After(challenge.ExpiresAt) {
+		return nil, pkg.ErrInvalidCode
+	}
+	if challenge.Attempts >= challengeMaxAttempts {
+		return nil, pkg.ErrTooManyRequests
+	}
+
+	user, err := s.repo.FindByUUID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// The account may have been disabled after the challenge was issued
+	if user.DisabledAt != nil {
+		return nil, pkg.ErrDisabled
+	}
+	ok, err := s.limitCode(user, func() (bool, error) { return s.checkSecondFactor(user, code) })
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		challenge.Attempts++
+		if err := s.repo.SaveLoginChallenge(challenge); err != nil {
+			return nil, err
+		}
+		return nil, pkg.ErrInvalidCode
+	}
+
+	challenge.Consumed = true
+	if err := s.repo.SaveLoginChallenge(challenge); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// limitCode runs check, which verifies a code entered for the user, under
+// the same lockout as Login. Failures are counted per user across all
+// challenges and sessions, a stolen token or password alone does not allow
+// guessing every code.
+func (s *service) limitCode(user *entities.User, check func() (bool, error)) (bool, error) {
+	now := time.Now()
+	attempt, err := s.attempts.Get(totpKey(user.UUID))
+	if err != nil {
+		return false, err
+	}
+	if wait := lockedFor(attempt, now); wait > 0 {
+		return false, &pkg.RetryError{Err: pkg.ErrLocked, RetryAfter: wait}
+	}
+	ok, err := check()
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return true, s.attempts.Delete(attempt.Key)
+	}
+	return false, s.fail(attempt.Key, s.policy.MaxAccountFailures, user.UUID, "", now)
+}
+
+// useTOTP checks a TOTP code and burns its time step, so the same code
+// is not accepted again.
+func (s *service) useTOTP(user *entities.User, code string) (bool, error) {
+	step, ok := ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
+	}
+	// Two requests with the same code may both get here, only one of them
+	// advances the step
+	advanced, err := s.repo.AdvanceTOTPStep(user.UUID, step)
+	if err != nil {
+		return false, err
+	}
+	user.TOTPLastStep = step
+	return advanced, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or one of the
+// unused recovery codes, which is burnt on success.
+func (s *service) checkSecondFactor(user *entities.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	ok, err := s.useTOTP(user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	codes, err := s.repo.GetRecoveryCodes(user.UUID)
+	if err != nil {
+		return false, err
+	}
+	hash := HashToken(strings.ToLower(code))
+	for _, rc := range *codes {
+		if rc.CodeHash == hash {
+			rc.Used = true
+			if err := s.repo.SaveRecoveryCode(&rc); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *service) UpdateProfile(userUUID string, update *ProfileUpdate) (*entities.User, error) {
+	if err := s.validateProfile(update); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.PhoneNumber != nil && *update.PhoneNumber != "" && *update.PhoneNumber != user.PhoneNumber {
+		// Phone numbers identify accounts for OTP login so they must be unique.
+		other, err := s.repo.FindByPhoneNumber(*update.PhoneNumber)
+		if err != nil && !errors.Is(err, pkg.ErrNotFound) {
+			return nil, err
+		}
+		if other != nil && other.UUID != user.UUID {
+			return nil, pkg.ErrExists
+		}
+	}
+
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	if update.PhoneNumber != nil {
+		user.PhoneNumber = *update.PhoneNumber
+	}
+	if update.ImageUrl != nil {
+		user.ImageUrl = *update.ImageUrl
+	}
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) RequestEmailChange(userUUID, newEmail string) error {
+	newEmail = normalizeEmail(newEmail)
+	if !strings.Contains(newEmail, "@") {
+		return pkg.ErrEmail
+	}
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return err
+	}
+	exists, err := s.repo.DoesEmailExist(newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return pkg.ErrExists
+	}
+
+	token, err := GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.CreateEmailChange(&entities.EmailChange{
+		UserID:    user.UUID,
+		NewEmail:  newEmail,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return s.emails.Send(
+		newEmail,
+		"Confirm your new QwikScan email",
+		fmt.Sprintf("Use this code to confirm your new email address: %s", token),
+	)
+}
+
+func (s *service) ConfirmEmailChange(token string) (*entities.User, error) {
+	change, err := s.repo.FindEmailChange(HashToken(token))
+	if errors.Is(err, pkg.ErrNotFound) {
+		return nil, pkg.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if change.Consumed || time.Now().After(change.ExpiresAt) {
+		return nil, pkg.ErrInvalidCode
+	}
+
+	// The address may have been registered since the change was requested.
+	exists, err := s.repo.DoesEmailExist(change.NewEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, pkg.ErrExists
+	}
+
+	user, err := s.repo.FindByUUID(change.UserID)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = change.NewEmail
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	change.Consumed = true
+	if err := s.repo.SaveEmailChange(change); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) ChangePassword(userUUID, currentPassword, newPassword, ip string) error {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(user, currentPassword, ip); err != nil {
+		return err
+	}
+	if len(newPassword) < 6 || len(newPassword) > 60 {
+		return pkg.ErrPassword
+	}
+	pass, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = pass
+	return s.repo.SaveUser(user)
+}
+
+func (s *service) DeleteAccount(userUUID, password, ip string, removeData func(userID string) error) error {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(user, password, ip); err != nil {
+		return err
+	}
+	if err := removeData(user.UUID); err != nil {
+		return err
+	}
+	keys := []string{accountKey(user.Email), totpKey(user.UUID)}
+	if err := s.repo.Anonymise(user); err != nil {
+		return err
+	}
+	// Anonymise removes the counters from the database, the memory store
+	// keeps its own
+	for _, key := range keys {
+		if err := s.attempts.Delete(key); err != nil {
+			return err
+		}
+	}
+	s.audit.Record(audit.ActionAccountDelete, userUUID, ip, "")
+	return nil
+}
+
+// roleStore checks that staff have a store and clears it for every other
+// role.
+func roleStore(role, storeID string) (string, error) {
+	switch role {
+	case entities.RoleStaff:
+		if storeID == "" {
+			return "", pkg.ErrNotAllowed
+		}
+		return storeID, nil
+	case entities.RoleUser, entities.RoleAdmin:
+		return "", nil
+	}
+	return "", pkg.ErrNotAllowed
+}
+
+func (s *service) SetRole(userUUID, role, storeID string) (*entities.User, error) {
+	storeID, err := roleStore(role, storeID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	user.StoreID = storeID
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) SetDisabled(userUUID string, disabled bool) (*entities.User, error) {
+	user, err := s.repo.FindByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if disabled == (user.DisabledAt != nil) {
+		return user, nil
+	}
+	action := audit.ActionAccountEnable
+	user.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		action = audit.ActionAccountDisable
+		user.DisabledAt = &now
+	}
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	s.audit.Record(action, userUUID, "", "")
+	return user, nil
+}
+
+func (s *service) CheckAccess(userUUID, role, storeID string) error {
+	user, err := s.repo.FindByUUID(userUUID)
+	if errors.Is(err, pkg.ErrNotFound) {
+		return pkg.ErrStaleToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return pkg.ErrDisabled
+	}
+	// Tokens of accounts registered before roles existed carry "user"
+	if user.Role == "" {
+		user.Role = entities.RoleUser
+	}
+	if user.Role != role || user.StoreID != storeID {
+		return pkg.ErrStaleToken
+	}
+	return nil
+}
+
 func (s *service) GetRepo() Repository {
 	return s.repo
 }
@@ -102,3 +891,17 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// GenerateNumericCode returns a cryptographically random code of n digits.
+func GenerateNumericCode(n int) (string, error) {
+	max := big.NewInt(10)
+	code := make([]byte, n)
+	for i := range code {
+		d, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + d.Int64())
+	}
+	return string(code), nil
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMSSender delivers text messages to a phone number.
+type SMSSender interface {
+	Send(phoneNumber, message string) error
+}
+
+type logSMSSender struct{}
+
+// NewLogSMSSender returns an SMSSender that only writes the message to the
+// log, meant for local runs where no SMS gateway is configured.
+func NewLogSMSSender() SMSSender {
+	return &logSMSSender{}
+}
+
+func (s *logSMSSender) Send(phoneNumber, message string) error {
+	log.Printf("SMS to %s: %s", phoneNumber, message)
+	return nil
+}
+
+const twilioAPI = "https://api.twilio.com/2010-04-01"
+
+type twilioSMSSender struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+}
+
+// NewTwilioSMSSender returns an SMSSender that sends through the Twilio
+// messages API from the given number.
+func NewTwilioSMSSender(accountSID, authToken, from string) SMSSender {
+	return &twilioSMSSender{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+func (s *twilioSMSSender) Send(phoneNumber, message string) error {
+	form := url.Values{}
+	form.Set("To", phoneNumber)
+	form.Set("From", s.from)
+	form.Set("Body", message)
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPI, url.PathEscape(s.accountSID))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("twilio: sending SMS failed with %s: %s", resp.Status, body)
+	}
+	return nil
+}
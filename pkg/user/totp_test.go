@@ -0,0 +1,234 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+)
+
+// rfcSecret is the key of the RFC 6238 test vectors, "12345678901234567890"
+// in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil || got != tt.want {
+			t.Errorf("TOTPCode at %d = %q, %v, want %q", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriod
+	code, _ := TOTPCode(rfcSecret, now)
+
+	got, ok := ValidateTOTP(rfcSecret, code, now, 0)
+	if !ok || got != step {
+		t.Fatalf("ValidateTOTP = %d, %v, want %d", got, ok, step)
+	}
+	// The previous and next steps are accepted for clock drift
+	if _, ok := ValidateTOTP(rfcSecret, code, now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Error("code of the previous step refused")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, code, now.Add(2*totpPeriod*time.Second), 0); ok {
+		t.Error("code two steps old accepted")
+	}
+	// A used step cannot be replayed, even within the skew
+	if _, ok := ValidateTOTP(rfcSecret, code, now, step); ok {
+		t.Error("code of the last used step accepted again")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, code, now.Add(totpPeriod*time.Second), step); ok {
+		t.Error("code of the last used step accepted in the next step")
+	}
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := ValidateTOTP(rfcSecret, bad, now, 0); ok {
+			t.Errorf("code %q accepted", bad)
+		}
+	}
+}
+
+// enrolled registers a user with two factor authentication on and returns
+// its secret and recovery codes.
+func enrolled(t *testing.T, s *service) (*entities.User, string, []string) {
+	t.Helper()
+	u, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _, err := s.EnrollTOTP(u.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := TOTPCode(secret, time.Now())
+	codes, err := s.ConfirmTOTP(u.UUID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	return u, secret, codes
+}
+
+// wrongCode returns a code that is not valid for secret right now.
+func wrongCode(secret string) string {
+	now := time.Now()
+	for _, c := range []string{"000000", "111111", "222222", "333333"} {
+		if _, ok := ValidateTOTP(secret, c, now, 0); !ok {
+			return c
+		}
+	}
+	panic("no wrong code")
+}
+
+func TestTOTPCodeUsedOnce(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	u, secret, _ := enrolled(t, s)
+
+	// The code that confirmed the enrolment has been burnt
+	code, _ := TOTPCode(secret, time.Now())
+	if err := s.DisableTOTP(u.UUID, code); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("confirmation code used again: %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestLoginWithRecoveryCode(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	_, _, codes := enrolled(t, s)
+
+	user, challenge, err := s.Login("ann@example.com", "secret1", "10.0.0.1")
+	if err != nil || user != nil || challenge == "" {
+		t.Fatalf("Login = %v, %q, %v, want a challenge", user, challenge, err)
+	}
+	// Recovery codes are accepted in either case
+	user, err = s.CompleteTOTPLogin(challenge, " "+codes[0]+" ")
+	if err != nil || user == nil {
+		t.Fatalf("CompleteTOTPLogin with a recovery code = %v, %v", user, err)
+	}
+	if _, err := s.CompleteTOTPLogin(challenge, codes[1]); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("challenge used twice: %v, want ErrInvalidCode", err)
+	}
+
+	_, challenge, err = s.Login("ann@example.com", "secret1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteTOTPLogin(challenge, codes[0]); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("recovery code used twice: %v, want ErrInvalidCode", err)
+	}
+	if _, err := s.CompleteTOTPLogin(challenge, codes[1]); err != nil {
+		t.Errorf("second recovery code: %v", err)
+	}
+}
+
+func TestDisableTOTPClearsRecoveryCodes(t *testing.T) {
+	repo := newMemRepo()
+	s := newTestService(repo, &sentSMS{})
+	u, _, codes := enrolled(t, s)
+
+	if err := s.DisableTOTP(u.UUID, codes[0]); err != nil {
+		t.Fatal(err)
+	}
+	left, _ := repo.GetRecoveryCodes(u.UUID)
+	if len(*left) != 0 {
+		t.Errorf("%d recovery codes left after disabling", len(*left))
+	}
+	u, _ = repo.FindByUUID(u.UUID)
+	if u.TOTPEnabled || u.TOTPSecret != "" {
+		t.Error("two factor authentication still on")
+	}
+}
+
+func TestTOTPCodesLockOut(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(s *service, userUUID, code string) error
+	}{
+		{"disable", func(s *service, userUUID, code string) error {
+			return s.DisableTOTP(userUUID, code)
+		}},
+		{"login", func(s *service, userUUID, code string) error {
+			// Every attempt on a fresh challenge
+			_, challenge, err := s.Login("ann@example.com", "secret1", "10.0.0.1")
+			if err != nil {
+				return err
+			}
+			_, err = s.CompleteTOTPLogin(challenge, code)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(newMemRepo(), &sentSMS{})
+			u, secret, codes := enrolled(t, s)
+			wrong := wrongCode(secret)
+			for i := 0; i < s.policy.MaxAccountFailures; i++ {
+				if err := tt.check(s, u.UUID, wrong); !errors.Is(err, pkg.ErrInvalidCode) {
+					t.Fatalf("attempt %d: %v, want ErrInvalidCode", i+1, err)
+				}
+			}
+			var retry *pkg.RetryError
+			err := tt.check(s, u.UUID, codes[0])
+			if !errors.As(err, &retry) || !errors.Is(err, pkg.ErrLocked) || retry.RetryAfter <= 0 {
+				t.Errorf("valid code while locked: %v, want ErrLocked with a retry time", err)
+			}
+		})
+	}
+}
+
+func TestConfirmTOTPLocksOut(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	u, err := s.Register(&entities.User{Name: "Ann", Email: "ann@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _, err := s.EnrollTOTP(u.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := wrongCode(secret)
+	for i := 0; i < s.policy.MaxAccountFailures; i++ {
+		if _, err := s.ConfirmTOTP(u.UUID, wrong); !errors.Is(err, pkg.ErrInvalidCode) {
+			t.Fatalf("attempt %d: %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	code, _ := TOTPCode(secret, time.Now())
+	if _, err := s.ConfirmTOTP(u.UUID, code); !errors.Is(err, pkg.ErrLocked) {
+		t.Errorf("valid code while locked: %v, want ErrLocked", err)
+	}
+}
+
+func TestTOTPSuccessResetsFailures(t *testing.T) {
+	s := newTestService(newMemRepo(), &sentSMS{})
+	u, secret, codes := enrolled(t, s)
+	wrong := wrongCode(secret)
+	for i := 0; i < s.policy.MaxAccountFailures-1; i++ {
+		if err := s.DisableTOTP(u.UUID, wrong); !errors.Is(err, pkg.ErrInvalidCode) {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	_, challenge, err := s.Login("ann@example.com", "secret1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteTOTPLogin(challenge, codes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DisableTOTP(u.UUID, wrong); !errors.Is(err, pkg.ErrInvalidCode) {
+		t.Errorf("failure after a success: %v, want ErrInvalidCode", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"github.com/rithikjain/quickscan-backend/pkg"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"time"
+)
+
+const (
+	demoStoreName = "Demo Store"
+	demoEmail     = "demo@example.com"
+	demoPassword  = "demo-password"
+)
+
+// demoProducts are sold at the demo store, prices are in paise.
+var demoProducts = []struct {
+	entities.Product
+	price int
+}{
+	{entities.Product{Barcode: "8901234000011", Name: "Whole Milk 1L", Category: "Dairy"}, 6800},
+	{entities.Product{Barcode: "8901234000028", Name: "Brown Bread", Category: "Bakery"}, 4500},
+	{entities.Product{Barcode: "8901234000035", Name: "Free Range Eggs 6 pack", Category: "Dairy"}, 7200},
+	{entities.Product{Barcode: "8901234000042", Name: "Basmati Rice 1kg", Category: "Staples"}, 14900},
+	{entities.Product{Barcode: "8901234000059", Name: "Bananas 1 dozen", Category: "Produce"}, 6000},
+	{entities.Product{Barcode: "8901234000066", Name: "Orange Juice 1L", Category: "Beverages"}, 12000},
+}
+
+type seeded struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// runSeed adds a demo store open around the clock, a few priced products
+// and a demo user to log in with. Running it again only adds what is
+// missing. It refuses to run on the server, the demo password is public.
+func runSeed(a *app, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: seed")
+	}
+	if a.config.OnServer {
+		return errors.New("seed is meant for local development and does not run on the server")
+	}
+
+	var done []seeded
+	s, status, err := seedStore(a)
+	if err != nil {
+		return err
+	}
+	done = append(done, seeded{"store", s.UUID, s.Name, status})
+
+	for _, p := range demoProducts {
+		product, err := a.catalog.GetProductByBarcode(p.Barcode)
+		status := statusExists
+		if errors.Is(err, pkg.ErrNotFound) {
+			created := p.Product
+			product, err = a.catalog.CreateProduct(&created)
+			status = statusCreated
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := a.catalog.SetPrice(s.UUID, product.UUID, p.price); err != nil {
+			return err
+		}
+		done = append(done, seeded{"product", product.UUID, product.Name, status})
+	}
+
+	u, err := a.users.GetUserByEmail(demoEmail)
+	status = statusExists
+	if errors.Is(err, pkg.ErrNotFound) {
+		u, err = a.users.Register(&entities.User{Name: "Demo User", Email: demoEmail, Password: demoPassword})
+		status = statusCreated
+	}
+	if err != nil {
+		return err
+	}
+	done = append(done, seeded{"user", u.UUID, demoEmail + " / " + demoPassword, status})
+
+	rows := make([][]string, len(done))
+	for i, d := range done {
+		rows[i] = []string{d.Kind, d.ID, d.Name, d.Status}
+	}
+	return out.print(done, []string{"KIND", "ID", "NAME", "STATUS"}, rows)
+}
+
+func seedStore(a *app) (*entities.Store, string, error) {
+	stores, err := a.stores.GetStores()
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range *stores {
+		if (*stores)[i].Name == demoStoreName {
+			return &(*stores)[i], statusExists, nil
+		}
+	}
+
+	s, err := a.stores.CreateStore(&entities.Store{
+		Name:      demoStoreName,
+		Address:   "1 Demo Street",
+		City:      "Vellore",
+		Latitude:  12.9692,
+		Longitude: 79.1559,
+		Timezone:  "Asia/Kolkata",
+		Currency:  "INR",
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	hours := make([]entities.StoreHours, 0, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		hours = append(hours, entities.StoreHours{StoreID: s.UUID, Weekday: day, Opens: "00:00", Closes: "24:00"})
+	}
+	if _, err := a.stores.SetHours(s.UUID, hours); err != nil {
+		return nil, "", err
+	}
+	return s, statusCreated, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/api/handler"
+	"github.com/rithikjain/quickscan-backend/api/middleware"
+	"github.com/rithikjain/quickscan-backend/api/rpc"
+	"github.com/rithikjain/quickscan-backend/pkg/cart"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// runServe serves the HTTP API and, on its own port, the gRPC API.
+func runServe(a *app, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("serve takes no arguments")
+	}
+
+	auth := middleware.NewAuth(a.config.JWT, a.users)
+
+	// Setting up the router
+	r := http.NewServeMux()
+	// Routes of the documented handler groups are recorded to check them
+	// against the OpenAPI spec
+	documented := handler.NewRouteRecorder(r)
+
+	handler.MakeUserHandler(documented, auth, a.users)
+	handler.MakeStoreHandler(r, a.stores)
+	handler.MakeInventoryHandler(r, auth, a.inventory)
+	handler.MakeCatalogHandler(r, a.catalog, a.inventory)
+	handler.MakeCartHandler(documented, auth, a.carts)
+
+	handler.MakeLayoutHandler(r, auth, a.layouts, a.carts)
+	handler.MakeShoppingListHandler(r, auth, a.lists, a.carts, a.layouts)
+	handler.MakeAnalyticsHandler(r, auth, a.analytics)
+
+	// Account deletion and data export span users, carts and lists
+	handler.MakeAccountHandler(r, auth, a.users, a.carts, a.lists)
+
+	// Resource oriented v2 API over the same services
+	handler.MakeV2Handler(r, auth, a.users, a.carts)
+
+	// GraphQL over the user and cart services
+	handler.MakeGraphQLHandler(r, auth, a.users, a.carts)
+
+	// API documentation, checked before anything is served
+	spec := handler.OpenAPISpec()
+	if missing := spec.Missing(documented.Patterns()); len(missing) > 0 {
+		return fmt.Errorf("routes missing from the OpenAPI spec: %v", missing)
+	}
+	handler.MakeDocsHandler(r, spec)
+
+	// Carts in the trash are purged once they are older than the retention
+	retention := time.Duration(a.config.CartTrashRetentionDays) * 24 * time.Hour
+	go cart.PurgeTrashEvery(a.carts, retention, time.Hour, nil)
+
+	// gRPC for internal consumers, served on its own port
+	lis, err := net.Listen("tcp", a.config.GRPCAddr())
+	if err != nil {
+		return fmt.Errorf("listening for gRPC: %w", err)
+	}
+	go func() {
+		log.Fatal(rpc.NewServer(auth, a.users, a.carts, a.cartEvents).Serve(lis))
+	}()
+
+	// To check if server up or not
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("Hello There"))
+		return
+	})
+
+	fmt.Println("Serving...")
+	// Behind the router the caller is found in X-Forwarded-For
+	clientIPs := middleware.NewClientIPs(a.config.TrustedProxies)
+	return http.ListenAndServe(a.config.HTTPAddr(), clientIPs.Handler(r))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rithikjain/quickscan-backend/pkg/entities"
+	"golang.org/x/crypto/ssh/terminal"
+	"os"
+	"strings"
+)
+
+func runUser(a *app, args []string) error {
+	name, args, err := subcommand("user", args, "create-admin", "disable", "set-role")
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "create-admin":
+		return createAdmin(a, args)
+	case "set-role":
+		return setRole(a, args)
+	default:
+		return disableUser(a, args)
+	}
+}
+
+// createAdmin registers an account and grants it the admin role. The
+// password is read from the terminal so it does not end up in the shell
+// history.
+func createAdmin(a *app, args []string) error {
+	fs := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the account")
+	name := fs.String("name", "Admin", "name of the account")
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *email == "" || fs.NArg() > 0 {
+		return errors.New("usage: user create-admin -email E [-name N]")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	u, err := a.users.RegisterWithRole(&entities.User{
+		Name:     *name,
+		Email:    *email,
+		Password: password,
+	}, entities.RoleAdmin, "")
+	if err != nil {
+		return err
+	}
+	return printUser(out, u)
+}
+
+// disableUser disables the account given by email or ID, or enables it
+// again with -enable.
+func disableUser(a *app, args []string) error {
+	fs := flag.NewFlagSet("user disable", flag.ContinueOnError)
+	enable := fs.Bool("enable", false, "enable the account again")
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: user disable [-enable] <email | id>")
+	}
+
+	u, err := findUser(a, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	u, err = a.users.SetDisabled(u.UUID, !*enable)
+	if err != nil {
+		return err
+	}
+	return printUser(out, u)
+}
+
+// setRole changes the role of the account given by email or ID. Staff are
+// assigned to the store they manage with -store.
+func setRole(a *app, args []string) error {
+	fs := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	role := fs.String("role", "", "user, staff or admin")
+	storeID := fs.String("store", "", "store of a staff member")
+	out, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *role == "" || fs.NArg() != 1 {
+		return errors.New("usage: user set-role -role R [-store id] <email | id>")
+	}
+	if *role == entities.RoleStaff {
+		if *storeID == "" {
+			return errors.New("staff need the -store they work at")
+		}
+		if _, err := a.stores.GetStore(*storeID); err != nil {
+			return fmt.Errorf("store %s: %w", *storeID, err)
+		}
+	}
+
+	u, err := findUser(a, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	u, err = a.users.SetRole(u.UUID, *role, *storeID)
+	if err != nil {
+		return err
+	}
+	return printUser(out, u)
+}
+
+// findUser looks the account up by email or, failing an @, by ID.
+func findUser(a *app, ref string) (*entities.User, error) {
+	if strings.Contains(ref, "@") {
+		return a.users.GetUserByEmail(ref)
+	}
+	return a.users.GetUserByUUID(ref)
+}
+
+func printUser(out *output, u *entities.User) error {
+	return out.print(u,
+		[]string{"ID", "EMAIL", "NAME", "ROLE", "STORE", "DISABLED AT"},
+		[][]string{{u.UUID, u.Email, u.Name, u.Role, u.StoreID, formatTime(u.DisabledAt)}},
+	)
+}
+
+// readPassword prompts for the password on a terminal, otherwise it reads
+// the first line of stdin.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("no password given on stdin")
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(password), nil
+}